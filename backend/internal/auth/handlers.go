@@ -2,22 +2,104 @@
 package auth
 
 import (
+	"context"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+	"github.com/{{.ProjectName}}/backend/internal/middleware"
 	"github.com/{{.ProjectName}}/backend/internal/supabase"
+	"github.com/{{.ProjectName}}/backend/internal/validation"
 )
 
+// Config holds auth handler configuration.
+type Config struct {
+	// RecoveryRedirectURL is where password recovery emails lead, typically
+	// an app deep link such as myapp://reset-password.
+	RecoveryRedirectURL string
+
+	// AllowedRedirectURLs lists the URLs clients may ask email links to
+	// lead to instead of the configured default.
+	AllowedRedirectURLs []string
+
+	// MagicLinkRedirectURL is where magic link emails lead.
+	MagicLinkRedirectURL string
+
+	// OTPCreateUser lets passwordless sign-in create accounts for unknown
+	// emails. When false only registered users receive a link or code.
+	OTPCreateUser bool
+
+	// OAuthProviders lists the OAuth providers users may sign in with, e.g.
+	// apple, google and github. OAuthRedirectURL is where the browser is
+	// sent after signing in, typically an app deep link.
+	OAuthProviders   []string
+	OAuthRedirectURL string
+
+	// PKCEStore keeps the verifiers of started OAuth flows. Defaults to an
+	// in-memory store.
+	PKCEStore PKCEStore
+
+	// Sessions is the registry of signed-in devices. Defaults to an
+	// in-memory store.
+	Sessions SessionStore
+
+	// Revocations, if set, receives the sessions ended by logouts, so that
+	// their access tokens are rejected before they expire. RevocationTTL
+	// is how long revocations without a known expiry are kept; it must be
+	// at least the access token lifetime.
+	Revocations   middleware.RevocationStore
+	RevocationTTL time.Duration
+
+	// VerifyToken, if set, verifies the access tokens of logouts, so that
+	// their sessions are ended on this server even when GoTrue is down.
+	VerifyToken func(ctx context.Context, token string) (*middleware.Claims, error)
+
+	// LoginThrottle slows down and locks out repeated failed sign-ins.
+	LoginThrottle LoginThrottle
+
+	// Policy is the password and registration policy.
+	Policy Policy
+}
+
 // Handler handles authentication requests.
 type Handler struct {
 	supabase *supabase.Client
+	config   Config
 }
 
 // NewHandler creates a new auth handler.
-func NewHandler(supabaseClient *supabase.Client) *Handler {
+func NewHandler(supabaseClient *supabase.Client, config Config) *Handler {
+	if config.PKCEStore == nil {
+		config.PKCEStore = NewMemoryPKCEStore()
+	}
+	if config.Sessions == nil {
+		config.Sessions = NewMemorySessionStore()
+	}
+	if config.LoginThrottle.Store == nil {
+		config.LoginThrottle.Store = NewMemoryAttemptStore()
+	}
 	return &Handler{
 		supabase: supabaseClient,
+		config:   config,
+	}
+}
+
+// redirectURL returns the URL an email link should lead to: requested if
+// it is allowed, or fallback when nothing was requested.
+func (h *Handler) redirectURL(requested, fallback string) (string, bool) {
+	if requested == "" {
+		return fallback, true
 	}
+	for _, allowed := range h.config.AllowedRedirectURLs {
+		if requested == allowed {
+			return requested, true
+		}
+	}
+	return "", false
 }
 
 // Register handles user registration.
@@ -31,21 +113,15 @@ func (h *Handler) Register(c echo.Context) error {
 		})
 	}
 
-	if req.Email == "" || req.Password == "" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "validation_error",
-			Message: "Email and password are required",
-		})
+	req.Email = strings.TrimSpace(req.Email)
+	if errors, ok := fieldErrors(c, &req); ok {
+		return validationError(c, errors)
 	}
-
-	if len(req.Password) < 6 {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "validation_error",
-			Message: "Password must be at least 6 characters",
-		})
+	if errors := h.config.Policy.CheckRegistration(req); len(errors) > 0 {
+		return validationError(c, errors)
 	}
 
-	resp, err := h.supabase.SignUp(req.Email, req.Password)
+	resp, err := h.supabase.SignUpContext(c.Request().Context(), req.Email, req.Password)
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, ErrorResponse{
 			Error:   "auth_error",
@@ -53,16 +129,8 @@ func (h *Handler) Register(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusCreated, AuthResponse{
-		AccessToken:  resp.AccessToken,
-		RefreshToken: resp.RefreshToken,
-		ExpiresIn:    resp.ExpiresIn,
-		User: User{
-			ID:        resp.User.ID,
-			Email:     resp.User.Email,
-			CreatedAt: resp.User.CreatedAt,
-		},
-	})
+	h.trackSession(c, resp)
+	return c.JSON(http.StatusCreated, NewAuthResponse(resp))
 }
 
 // Login handles user login.
@@ -76,14 +144,34 @@ func (h *Handler) Login(c echo.Context) error {
 		})
 	}
 
-	if req.Email == "" || req.Password == "" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "validation_error",
-			Message: "Email and password are required",
+	if errors, ok := fieldErrors(c, &req); ok {
+		return validationError(c, errors)
+	}
+
+	ctx := c.Request().Context()
+	throttle := &h.config.LoginThrottle
+	ip := c.RealIP()
+
+	// The attempt counts as failed until the sign-in succeeds
+	wait, err := throttle.Attempt(ctx, req.Email, ip)
+	if err != nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "service_unavailable",
+			Message: "Sign-in temporarily unavailable",
 		})
 	}
+	if wait > 0 {
+		return tooManyAttempts(c, wait)
+	}
 
-	resp, err := h.supabase.SignIn(req.Email, req.Password)
+	resp, err := h.supabase.SignInContext(ctx, req.Email, req.Password)
+	if supabase.IsUnavailable(err) {
+		_ = throttle.Aborted(ctx, req.Email, ip)
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "service_unavailable",
+			Message: "Auth service temporarily unavailable",
+		})
+	}
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, ErrorResponse{
 			Error:   "auth_error",
@@ -91,15 +179,37 @@ func (h *Handler) Login(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, AuthResponse{
-		AccessToken:  resp.AccessToken,
-		RefreshToken: resp.RefreshToken,
-		ExpiresIn:    resp.ExpiresIn,
-		User: User{
-			ID:        resp.User.ID,
-			Email:     resp.User.Email,
-			CreatedAt: resp.User.CreatedAt,
-		},
+	_ = throttle.Succeeded(ctx, req.Email, ip)
+	h.trackSession(c, resp)
+	return c.JSON(http.StatusOK, NewAuthResponse(resp))
+}
+
+// fieldErrors checks the validate tags of req, returning the errors of the
+// fields that break them.
+func fieldErrors(c echo.Context, req interface{}) (map[string]string, bool) {
+	if err := c.Validate(req); err != nil {
+		return validation.Errors(c, err)
+	}
+	return nil, false
+}
+
+// validationError rejects a request with field-level errors.
+func validationError(c echo.Context, errors map[string]string) error {
+	return c.JSON(http.StatusBadRequest, ErrorResponse{
+		Error:   "validation_error",
+		Message: "Validation failed",
+		Details: errors,
+	})
+}
+
+// tooManyAttempts rejects a throttled sign-in, telling the client when to
+// retry.
+func tooManyAttempts(c echo.Context, wait time.Duration) error {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.JSON(http.StatusTooManyRequests, ErrorResponse{
+		Error:   "too_many_attempts",
+		Message: "Too many failed sign-in attempts, try again later",
 	})
 }
 
@@ -114,14 +224,12 @@ func (h *Handler) Refresh(c echo.Context) error {
 		})
 	}
 
-	if req.RefreshToken == "" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "validation_error",
-			Message: "Refresh token is required",
-		})
+	if errors, ok := fieldErrors(c, &req); ok {
+		return validationError(c, errors)
 	}
 
-	resp, err := h.supabase.RefreshToken(req.RefreshToken)
+	ctx := c.Request().Context()
+	resp, err := h.supabase.RefreshTokenContext(ctx, req.RefreshToken)
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, ErrorResponse{
 			Error:   "auth_error",
@@ -129,20 +237,23 @@ func (h *Handler) Refresh(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, AuthResponse{
-		AccessToken:  resp.AccessToken,
-		RefreshToken: resp.RefreshToken,
-		ExpiresIn:    resp.ExpiresIn,
-		User: User{
-			ID:        resp.User.ID,
-			Email:     resp.User.Email,
-			CreatedAt: resp.User.CreatedAt,
-		},
-	})
+	// A session revoked from another device ends at its next refresh.
+	if session, ok := sessionFromRequest(c, resp.AccessToken); ok {
+		if revoked, _ := h.config.Sessions.IsRevoked(ctx, session.ID); revoked {
+			_ = h.supabase.SignOutScope(ctx, resp.AccessToken, supabase.LogoutLocal)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "session_revoked",
+				Message: "Session was signed out",
+			})
+		}
+	}
+
+	h.trackSession(c, resp)
+	return c.JSON(http.StatusOK, NewAuthResponse(resp))
 }
 
 // Logout handles user logout.
-// POST /auth/logout
+// POST /auth/logout?scope=local|others|global
 func (h *Handler) Logout(c echo.Context) error {
 	authHeader := c.Request().Header.Get("Authorization")
 	if authHeader == "" {
@@ -165,10 +276,449 @@ func (h *Handler) Logout(c echo.Context) error {
 		})
 	}
 
-	// Best effort logout - ignore errors
-	_ = h.supabase.SignOut(token)
+	// Which sessions to end: this one (local), every other one (others),
+	// or all of them (global, GoTrue's default).
+	scope := c.QueryParam("scope")
+	switch scope {
+	case "":
+		scope = supabase.LogoutGlobal
+	case supabase.LogoutLocal, supabase.LogoutOthers, supabase.LogoutGlobal:
+	default:
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Scope must be local, others or global",
+		})
+	}
+
+	// End the sessions on this server first, so that their tokens stop
+	// working even if GoTrue cannot be reached. Only a verified token may
+	// do so, or a forged one could sign anyone out.
+	ctx := c.Request().Context()
+	ended := false
+	if h.config.VerifyToken != nil {
+		if claims, err := h.config.VerifyToken(ctx, token); err == nil && claims.SessionID != "" {
+			h.endSessions(ctx, claims.Sub, claims.SessionID, claims.ExpiresAt, scope)
+			ended = true
+		}
+	}
+
+	err := h.supabase.SignOutScope(ctx, token, scope)
+	if err == nil && !ended {
+		// GoTrue accepted the token, e.g. one that expired since.
+		if claims, ok := parseTokenClaims(token); ok {
+			h.endSessions(ctx, claims.Sub, claims.SessionID, claims.ExpiresAt, scope)
+		}
+	}
+	if err != nil {
+		log.Printf("logout: GoTrue sign out failed: %v", err)
+		if supabase.IsUnavailable(err) {
+			return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+				Error:   "service_unavailable",
+				Message: "Auth service temporarily unavailable",
+			})
+		}
+	}
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"message": "Logged out successfully",
 	})
 }
+
+// SendOTP emails a magic link and a 6-digit sign-in code. The response is
+// the same whether or not the email is registered.
+// POST /auth/otp
+func (h *Handler) SendOTP(c echo.Context) error {
+	var req OTPRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if errors, ok := fieldErrors(c, &req); ok {
+		return validationError(c, errors)
+	}
+
+	redirectTo, ok := h.redirectURL(req.RedirectTo, h.config.MagicLinkRedirectURL)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Redirect URL is not allowed",
+		})
+	}
+
+	// As for recovery, only an outage is reported.
+	// Accounts are only created for emails registration would accept.
+	createUser := h.config.OTPCreateUser && !h.config.Policy.InviteOnly &&
+		h.config.Policy.CheckEmail(req.Email) == ""
+	err := h.supabase.SignInWithOTP(c.Request().Context(), req.Email, redirectTo, createUser)
+	if supabase.IsUnavailable(err) {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "service_unavailable",
+			Message: "Auth service temporarily unavailable",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "If an account exists for this email, a sign-in link has been sent",
+	})
+}
+
+// VerifyOTP signs a user in with an emailed code or the token hash of a
+// magic link.
+// POST /auth/verify
+func (h *Handler) VerifyOTP(c echo.Context) error {
+	var req VerifyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if errors, ok := fieldErrors(c, &req); ok {
+		return validationError(c, errors)
+	}
+
+	params := supabase.VerifyOTPParams{Type: req.Type}
+	if params.Type == "" {
+		params.Type = supabase.OTPTypeEmail
+	}
+	if req.TokenHash != "" {
+		params.TokenHash = req.TokenHash
+	} else {
+		params.Email = req.Email
+		params.Token = req.Token
+	}
+
+	resp, err := h.supabase.VerifyOTP(c.Request().Context(), params)
+	if supabase.IsUnavailable(err) {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "service_unavailable",
+			Message: "Auth service temporarily unavailable",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "auth_error",
+			Message: "Invalid or expired code",
+		})
+	}
+
+	h.trackSession(c, resp)
+	return c.JSON(http.StatusOK, NewAuthResponse(resp))
+}
+
+// OAuthStart starts an OAuth PKCE sign-in with a provider. It returns the
+// authorize URL to open in the app's browser session and the state to send
+// to OAuthCallback with the returned code.
+// POST /auth/oauth/:provider
+func (h *Handler) OAuthStart(c echo.Context) error {
+	var req OAuthStartRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if errors, ok := fieldErrors(c, &req); ok {
+		return validationError(c, errors)
+	}
+	if !h.oauthProviderEnabled(req.Provider) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "OAuth provider is not enabled",
+		})
+	}
+
+	redirectTo, ok := h.redirectURL(req.RedirectTo, h.config.OAuthRedirectURL)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Redirect URL is not allowed",
+		})
+	}
+
+	state, err := randomToken(32)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to start sign-in",
+		})
+	}
+	verifier, err := randomToken(32)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to start sign-in",
+		})
+	}
+
+	if err := h.config.PKCEStore.Save(c.Request().Context(), state, verifier, pkceTTL); err != nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "service_unavailable",
+			Message: "Failed to start sign-in",
+		})
+	}
+
+	return c.JSON(http.StatusOK, OAuthStartResponse{
+		URL:       h.supabase.AuthorizeURL(req.Provider, redirectTo, codeChallenge(verifier)),
+		State:     state,
+		ExpiresIn: int(pkceTTL.Seconds()),
+	})
+}
+
+// OAuthCallback completes an OAuth PKCE sign-in, exchanging the code the
+// app received on its redirect URL for a session.
+// POST /auth/oauth/callback
+func (h *Handler) OAuthCallback(c echo.Context) error {
+	var req OAuthCallbackRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if errors, ok := fieldErrors(c, &req); ok {
+		return validationError(c, errors)
+	}
+
+	ctx := c.Request().Context()
+	verifier, err := h.config.PKCEStore.Take(ctx, req.State)
+	if err == ErrUnknownState {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_state",
+			Message: "Sign-in expired or was already completed, please start again",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "service_unavailable",
+			Message: "Failed to complete sign-in",
+		})
+	}
+
+	resp, err := h.supabase.ExchangeCodeForSession(ctx, req.Code, verifier)
+	if supabase.IsUnavailable(err) {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "service_unavailable",
+			Message: "Auth service temporarily unavailable",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "auth_error",
+			Message: "Invalid or expired authorization code",
+		})
+	}
+
+	h.trackSession(c, resp)
+	return c.JSON(http.StatusOK, NewAuthResponse(resp))
+}
+
+// oauthProviderEnabled reports whether users may sign in with provider.
+func (h *Handler) oauthProviderEnabled(provider string) bool {
+	for _, enabled := range h.config.OAuthProviders {
+		if provider == enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// Recover sends a password recovery email. The response is the same
+// whether or not the email is registered.
+// POST /auth/recover
+func (h *Handler) Recover(c echo.Context) error {
+	var req RecoverRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if errors, ok := fieldErrors(c, &req); ok {
+		return validationError(c, errors)
+	}
+
+	redirectTo, ok := h.redirectURL(req.RedirectTo, h.config.RecoveryRedirectURL)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Redirect URL is not allowed",
+		})
+	}
+
+	// Only an outage is reported; any other failure would tell the caller
+	// something about the account.
+	err := h.supabase.RecoverPassword(c.Request().Context(), req.Email, redirectTo)
+	if supabase.IsUnavailable(err) {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "service_unavailable",
+			Message: "Auth service temporarily unavailable",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "If an account exists for this email, a recovery link has been sent",
+	})
+}
+
+// ResetPassword sets a new password using the access token or token hash
+// from a recovery link.
+// POST /auth/reset-password
+func (h *Handler) ResetPassword(c echo.Context) error {
+	var req ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+	}
+
+	if (req.AccessToken == "") == (req.TokenHash == "") {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Either access_token or token_hash is required",
+		})
+	}
+
+	if errors, ok := fieldErrors(c, &req); ok {
+		return validationError(c, errors)
+	}
+	if msg := h.config.Policy.CheckPassword(req.Password); msg != "" {
+		return validationError(c, map[string]string{"password": msg})
+	}
+
+	// Only sessions created from a recovery or one-time code email may set
+	// a password without the current one. The claims are checked before
+	// GoTrue verifies the token, which UpdateUser does.
+	if req.AccessToken != "" {
+		claims, ok := parseTokenClaims(req.AccessToken)
+		if !ok || !claims.authenticatedBy("recovery", "otp") {
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "auth_error",
+				Message: "Invalid or expired recovery link",
+			})
+		}
+	}
+
+	ctx := c.Request().Context()
+	accessToken := req.AccessToken
+	if req.TokenHash != "" {
+		session, err := h.supabase.VerifyOTP(ctx, supabase.VerifyOTPParams{
+			Type:      supabase.OTPTypeRecovery,
+			TokenHash: req.TokenHash,
+		})
+		if err != nil {
+			return recoveryError(c, err)
+		}
+		accessToken = session.AccessToken
+	}
+
+	password := req.Password
+	if _, err := h.supabase.UpdateUser(ctx, accessToken, supabase.UserAttributes{Password: &password}); err != nil {
+		return recoveryError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Password has been reset",
+	})
+}
+
+// recoveryError maps a failed recovery verification or password update to
+// an API response.
+func recoveryError(c echo.Context, err error) error {
+	if supabase.IsUnavailable(err) {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "service_unavailable",
+			Message: "Auth service temporarily unavailable",
+		})
+	}
+
+	if authErr, ok := supabase.AsAuthAPIError(err); ok && authErr.Status == http.StatusUnprocessableEntity {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: authErr.Message,
+		})
+	}
+
+	return c.JSON(http.StatusUnauthorized, ErrorResponse{
+		Error:   "auth_error",
+		Message: "Invalid or expired recovery link",
+	})
+}
+
+// trackSession records the device a session was created or refreshed from.
+func (h *Handler) trackSession(c echo.Context, resp *supabase.AuthResponse) {
+	TrackSession(c, h.config.Sessions, resp)
+}
+
+// endSessions removes the sessions a logout with scope ends from the
+// registry, and revokes their access tokens.
+func (h *Handler) endSessions(ctx context.Context, userID, sessionID string, expiresAt *jwt.NumericDate, scope string) {
+	until := time.Now().Add(h.config.RevocationTTL)
+	switch scope {
+	case supabase.LogoutLocal:
+		// Older tokens of the session expire before this one.
+		if expiresAt != nil {
+			until = expiresAt.Time
+		}
+		h.revoke(ctx, middleware.SessionRevocationKey(sessionID), until)
+		_, _ = h.config.Sessions.Revoke(ctx, userID, sessionID)
+	case supabase.LogoutOthers:
+		sessions, _ := h.config.Sessions.List(ctx, userID)
+		for _, session := range sessions {
+			if session.ID != sessionID {
+				h.revoke(ctx, middleware.SessionRevocationKey(session.ID), until)
+			}
+		}
+		_ = h.config.Sessions.RevokeOthers(ctx, userID, sessionID)
+	default:
+		h.revoke(ctx, middleware.UserRevocationKey(userID), until)
+		_ = h.config.Sessions.RevokeOthers(ctx, userID, "")
+	}
+}
+
+// revoke adds key to the revocation list, if one is configured.
+func (h *Handler) revoke(ctx context.Context, key string, until time.Time) {
+	if h.config.Revocations == nil {
+		return
+	}
+	if err := h.config.Revocations.Revoke(ctx, key, until); err != nil {
+		log.Printf("logout: revoke %s: %v", key, err)
+	}
+}
+
+// NewAuthResponse converts a Supabase session to the API response.
+func NewAuthResponse(resp *supabase.AuthResponse) AuthResponse {
+	return AuthResponse{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    resp.ExpiresIn,
+		User:         NewUser(&resp.User),
+	}
+}
+
+// NewUser converts a Supabase user to the API user, reading the profile
+// fields from its user metadata.
+func NewUser(u *supabase.User) User {
+	return User{
+		ID:          u.ID,
+		Email:       u.Email,
+		DisplayName: metadataString(u.UserMetadata, MetadataDisplayName),
+		AvatarURL:   metadataString(u.UserMetadata, MetadataAvatarURL),
+		Locale:      metadataString(u.UserMetadata, MetadataLocale),
+		Timezone:    metadataString(u.UserMetadata, MetadataTimezone),
+		CreatedAt:   u.CreatedAt,
+	}
+}
+
+func metadataString(metadata map[string]interface{}, key string) string {
+	value, _ := metadata[key].(string)
+	return value
+}
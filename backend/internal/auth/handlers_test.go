@@ -0,0 +1,385 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+	"github.com/{{.ProjectName}}/backend/internal/validation"
+)
+
+// fakeGoTrue is an httptest stand-in for GoTrue that answers every request
+// with a fixed response and records the requests it receives.
+type fakeGoTrue struct {
+	*httptest.Server
+	status int
+	body   string
+
+	requests []gotrueRequest
+}
+
+type gotrueRequest struct {
+	path       string
+	redirectTo string
+	json       map[string]interface{}
+}
+
+func newFakeGoTrue(t *testing.T, status int, body string) *fakeGoTrue {
+	t.Helper()
+
+	f := &fakeGoTrue{status: status, body: body}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := gotrueRequest{path: r.URL.Path, redirectTo: r.URL.Query().Get("redirect_to")}
+		_ = json.NewDecoder(r.Body).Decode(&req.json)
+		f.requests = append(f.requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.status)
+		_, _ = w.Write([]byte(f.body))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// testSession returns a GoTrue session response whose access token belongs
+// to session-1 of user-1.
+func testSession(t *testing.T) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":        "user-1",
+		"session_id": "session-1",
+		"exp":        time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"access_token":  token,
+		"refresh_token": "refresh",
+		"expires_in":    3600,
+		"token_type":    "bearer",
+		"user":          map[string]string{"id": "user-1", "email": "a@example.com"},
+	})
+	return string(body)
+}
+
+// serve calls handler with a JSON request body and returns the response.
+func serve(handler echo.HandlerFunc, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.Validator = validation.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(HeaderDeviceName, "Test phone")
+	rec := httptest.NewRecorder()
+	if err := handler(e.NewContext(req, rec)); err != nil {
+		e.HTTPErrorHandler(err, e.NewContext(req, rec))
+	}
+	return rec
+}
+
+// errorCode returns the error code of an error response.
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var resp ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+	return resp.Error
+}
+
+func TestSendOTP(t *testing.T) {
+	const sent = "If an account exists for this email, a sign-in link has been sent"
+
+	tests := []struct {
+		name         string
+		config       Config
+		status       int
+		body         string
+		request      string
+		wantStatus   int
+		wantError    string
+		wantCalled   bool
+		wantCreate   bool
+		wantRedirect string
+	}{
+		{
+			name:         "registered email",
+			config:       Config{MagicLinkRedirectURL: "myapp://signed-in"},
+			status:       http.StatusOK,
+			body:         `{}`,
+			request:      `{"email":"a@example.com"}`,
+			wantStatus:   http.StatusOK,
+			wantCalled:   true,
+			wantRedirect: "myapp://signed-in",
+		},
+		{
+			name:       "unknown email without create_user",
+			status:     http.StatusUnprocessableEntity,
+			body:       `{"code":422,"error_code":"otp_disabled","msg":"Signups not allowed for otp"}`,
+			request:    `{"email":"unknown@example.com"}`,
+			wantStatus: http.StatusOK,
+			wantCalled: true,
+		},
+		{
+			name:       "creates users",
+			config:     Config{OTPCreateUser: true},
+			status:     http.StatusOK,
+			body:       `{}`,
+			request:    `{"email":"new@example.com"}`,
+			wantStatus: http.StatusOK,
+			wantCalled: true,
+			wantCreate: true,
+		},
+		{
+			name:       "does not create users the policy rejects",
+			config:     Config{OTPCreateUser: true, Policy: Policy{DeniedEmailDomains: []string{"example.com"}}},
+			status:     http.StatusOK,
+			body:       `{}`,
+			request:    `{"email":"new@example.com"}`,
+			wantStatus: http.StatusOK,
+			wantCalled: true,
+		},
+		{
+			name:       "does not create users when invite-only",
+			config:     Config{OTPCreateUser: true, Policy: Policy{InviteOnly: true, InviteCodes: []string{"code"}}},
+			status:     http.StatusOK,
+			body:       `{}`,
+			request:    `{"email":"new@example.com"}`,
+			wantStatus: http.StatusOK,
+			wantCalled: true,
+		},
+		{
+			name:         "allowed redirect",
+			config:       Config{MagicLinkRedirectURL: "myapp://signed-in", AllowedRedirectURLs: []string{"myapp://other"}},
+			status:       http.StatusOK,
+			body:         `{}`,
+			request:      `{"email":"a@example.com","redirect_to":"myapp://other"}`,
+			wantStatus:   http.StatusOK,
+			wantCalled:   true,
+			wantRedirect: "myapp://other",
+		},
+		{
+			name:       "redirect not allowed",
+			config:     Config{AllowedRedirectURLs: []string{"myapp://other"}},
+			request:    `{"email":"a@example.com","redirect_to":"https://evil.example"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "validation_error",
+		},
+		{
+			name:       "invalid email",
+			request:    `{"email":"not-an-email"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "validation_error",
+		},
+		{
+			name:       "malformed body",
+			request:    `{"email":`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_request",
+		},
+		{
+			name:       "GoTrue unavailable",
+			status:     http.StatusServiceUnavailable,
+			body:       `{"msg":"unavailable"}`,
+			request:    `{"email":"a@example.com"}`,
+			wantStatus: http.StatusServiceUnavailable,
+			wantError:  "service_unavailable",
+			wantCalled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotrue := newFakeGoTrue(t, tt.status, tt.body)
+			h := NewHandler(supabase.NewClient(gotrue.URL, "anon-key"), tt.config)
+
+			rec := serve(h.SendOTP, tt.request)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantError != "" {
+				if got := errorCode(t, rec); got != tt.wantError {
+					t.Errorf("error = %q, want %q", got, tt.wantError)
+				}
+			} else if !strings.Contains(rec.Body.String(), sent) {
+				t.Errorf("body = %s, want the generic message", rec.Body.String())
+			}
+
+			if !tt.wantCalled {
+				if len(gotrue.requests) != 0 {
+					t.Errorf("GoTrue called %d times, want none", len(gotrue.requests))
+				}
+				return
+			}
+			if len(gotrue.requests) != 1 {
+				t.Fatalf("GoTrue called %d times, want once", len(gotrue.requests))
+			}
+			req := gotrue.requests[0]
+			if req.path != "/auth/v1/otp" {
+				t.Errorf("path = %q", req.path)
+			}
+			if req.redirectTo != tt.wantRedirect {
+				t.Errorf("redirect_to = %q, want %q", req.redirectTo, tt.wantRedirect)
+			}
+			if got := req.json["create_user"]; got != tt.wantCreate {
+				t.Errorf("create_user = %v, want %v", got, tt.wantCreate)
+			}
+		})
+	}
+}
+
+func TestVerifyOTP(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		request    string
+		wantStatus int
+		wantError  string
+		wantSent   map[string]interface{}
+	}{
+		{
+			name:       "code",
+			status:     http.StatusOK,
+			request:    `{"email":"a@example.com","token":"123456"}`,
+			wantStatus: http.StatusOK,
+			wantSent:   map[string]interface{}{"type": "email", "email": "a@example.com", "token": "123456"},
+		},
+		{
+			name:       "token hash",
+			status:     http.StatusOK,
+			request:    `{"token_hash":"pkce_abc","type":"magiclink"}`,
+			wantStatus: http.StatusOK,
+			wantSent:   map[string]interface{}{"type": "magiclink", "token_hash": "pkce_abc"},
+		},
+		{
+			name:       "signup token hash",
+			status:     http.StatusOK,
+			request:    `{"token_hash":"pkce_abc","type":"signup"}`,
+			wantStatus: http.StatusOK,
+			wantSent:   map[string]interface{}{"type": "signup", "token_hash": "pkce_abc"},
+		},
+		{
+			name:       "code without email",
+			request:    `{"token":"123456"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "validation_error",
+		},
+		{
+			name:       "neither code nor token hash",
+			request:    `{"email":"a@example.com"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "validation_error",
+		},
+		{
+			name:       "code with invalid email",
+			request:    `{"email":"not-an-email","token":"123456"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "validation_error",
+		},
+		{
+			name:       "code and token hash",
+			request:    `{"email":"a@example.com","token":"123456","token_hash":"pkce_abc"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "validation_error",
+		},
+		{
+			name:       "recovery type",
+			request:    `{"token_hash":"pkce_abc","type":"recovery"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "validation_error",
+		},
+		{
+			name:       "expired code",
+			status:     http.StatusForbidden,
+			body:       `{"code":403,"error_code":"otp_expired","msg":"Token has expired or is invalid"}`,
+			request:    `{"email":"a@example.com","token":"000000"}`,
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "auth_error",
+			wantSent:   map[string]interface{}{"type": "email", "email": "a@example.com", "token": "000000"},
+		},
+		{
+			name:       "GoTrue unavailable",
+			status:     http.StatusBadGateway,
+			body:       `{"msg":"bad gateway"}`,
+			request:    `{"token_hash":"pkce_abc"}`,
+			wantStatus: http.StatusServiceUnavailable,
+			wantError:  "service_unavailable",
+			wantSent:   map[string]interface{}{"type": "email", "token_hash": "pkce_abc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.body
+			if body == "" {
+				body = testSession(t)
+			}
+			gotrue := newFakeGoTrue(t, tt.status, body)
+			sessions := NewMemorySessionStore()
+			h := NewHandler(supabase.NewClient(gotrue.URL, "anon-key"), Config{Sessions: sessions})
+
+			rec := serve(h.VerifyOTP, tt.request)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+
+			if tt.wantSent == nil {
+				if len(gotrue.requests) != 0 {
+					t.Errorf("GoTrue called %d times, want none", len(gotrue.requests))
+				}
+			} else {
+				if len(gotrue.requests) != 1 {
+					t.Fatalf("GoTrue called %d times, want once", len(gotrue.requests))
+				}
+				req := gotrue.requests[0]
+				if req.path != "/auth/v1/verify" {
+					t.Errorf("path = %q", req.path)
+				}
+				if !jsonEqual(req.json, tt.wantSent) {
+					t.Errorf("sent %v, want %v", req.json, tt.wantSent)
+				}
+			}
+
+			listed, _ := sessions.List(context.Background(), "user-1")
+			if tt.wantError != "" {
+				if got := errorCode(t, rec); got != tt.wantError {
+					t.Errorf("error = %q, want %q", got, tt.wantError)
+				}
+				if len(listed) != 0 {
+					t.Errorf("failed verification recorded sessions %v", listed)
+				}
+				return
+			}
+
+			var resp AuthResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.RefreshToken != "refresh" || resp.User.ID != "user-1" {
+				t.Errorf("response = %+v", resp)
+			}
+			if len(listed) != 1 || listed[0].ID != "session-1" || listed[0].DeviceName != "Test phone" {
+				t.Errorf("sessions = %+v, want session-1 from the test phone", listed)
+			}
+		})
+	}
+}
+
+func jsonEqual(a, b map[string]interface{}) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+)
+
+// pkceTTL is how long a started OAuth flow can be completed.
+const pkceTTL = 10 * time.Minute
+
+// ErrUnknownState is returned by PKCEStore.Take for states that were never
+// issued, already used or expired.
+var ErrUnknownState = errors.New("unknown or expired OAuth state")
+
+// PKCEStore keeps the code verifiers of started OAuth flows, keyed by state.
+type PKCEStore interface {
+	// Save stores verifier for state until ttl elapses.
+	Save(ctx context.Context, state, verifier string, ttl time.Duration) error
+	// Take returns and removes the verifier for state.
+	Take(ctx context.Context, state string) (string, error)
+}
+
+// randomToken returns a URL-safe random string from n random bytes.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge returns the S256 PKCE challenge for verifier.
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// pkceEntry is a stored verifier.
+type pkceEntry struct {
+	verifier  string
+	expiresAt time.Time
+}
+
+// MemoryPKCEStore is an in-process PKCEStore. Flows must be completed on
+// the instance that started them.
+type MemoryPKCEStore struct {
+	mu        sync.Mutex
+	entries   map[string]pkceEntry
+	lastSweep time.Time
+}
+
+// NewMemoryPKCEStore creates an empty in-memory store.
+func NewMemoryPKCEStore() *MemoryPKCEStore {
+	return &MemoryPKCEStore{entries: make(map[string]pkceEntry)}
+}
+
+// Save implements PKCEStore.
+func (s *MemoryPKCEStore) Save(_ context.Context, state, verifier string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+	s.entries[state] = pkceEntry{verifier: verifier, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// Take implements PKCEStore.
+func (s *MemoryPKCEStore) Take(_ context.Context, state string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[state]
+	delete(s.entries, state)
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return "", ErrUnknownState
+	}
+	return entry.verifier, nil
+}
+
+// sweep drops expired entries at most once a minute. Callers hold s.mu.
+func (s *MemoryPKCEStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for state, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, state)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Character classes a password can be required to contain.
+const (
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+const (
+	// defaultMinPasswordLength is the GoTrue minimum, used when the policy
+	// sets none.
+	defaultMinPasswordLength = 6
+	// maxPasswordLength is the longest password GoTrue accepts, in bytes.
+	maxPasswordLength = 72
+	// maxEmailLength is the longest deliverable email address.
+	maxEmailLength = 254
+)
+
+// breachedPasswordList lists common passwords from public breach corpora, one
+// per line, lowercased.
+//
+//go:embed breached_passwords.txt
+var breachedPasswordList string
+
+// disposableDomainList lists domains of throwaway email services, one per
+// line.
+//
+//go:embed disposable_domains.txt
+var disposableDomainList string
+
+var (
+	breachedPasswords = lineSet(breachedPasswordList)
+	disposableDomains = lineSet(disposableDomainList)
+)
+
+// lineSet returns the non-empty, non-comment lines of list.
+func lineSet(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line != "" && !strings.HasPrefix(line, "#") {
+			set[line] = true
+		}
+	}
+	return set
+}
+
+// Policy is the password and registration policy. Password rules apply
+// wherever a password is set; email rules to registration and email
+// changes. OAuth sign-in creates accounts in GoTrue directly and is not
+// covered; restrict it with the enabled providers.
+type Policy struct {
+	// MinPasswordLength is the minimum password length in characters.
+	// Defaults to 6.
+	MinPasswordLength int
+	// RequiredClasses lists the character classes every password must
+	// contain: lower, upper, digit and symbol.
+	RequiredClasses []string
+	// RejectBreached rejects passwords on the bundled list of common
+	// breached passwords.
+	RejectBreached bool
+
+	// AllowedEmailDomains, if set, are the only domains that may register.
+	// DeniedEmailDomains may not. Both match subdomains too.
+	AllowedEmailDomains []string
+	DeniedEmailDomains  []string
+	// BlockDisposableEmails rejects domains on the bundled list of
+	// throwaway email services.
+	BlockDisposableEmails bool
+
+	// InviteOnly requires one of InviteCodes to register.
+	InviteOnly  bool
+	InviteCodes []string
+}
+
+// CheckPassword returns why password breaks the policy, or "" if it does
+// not.
+func (p *Policy) CheckPassword(password string) string {
+	minLength := p.MinPasswordLength
+	if minLength <= 0 {
+		minLength = defaultMinPasswordLength
+	}
+	if utf8.RuneCountInString(password) < minLength {
+		return fmt.Sprintf("Password must be at least %d characters", minLength)
+	}
+	if len(password) > maxPasswordLength {
+		return fmt.Sprintf("Password must be at most %d bytes", maxPasswordLength)
+	}
+
+	var missing []string
+	for _, class := range p.RequiredClasses {
+		if !hasClass(password, class) {
+			missing = append(missing, classNames[class])
+		}
+	}
+	if len(missing) > 0 {
+		return "Password must contain " + joinWords(missing)
+	}
+
+	if p.RejectBreached && breachedPasswords[strings.ToLower(password)] {
+		return "Password is too common, choose another one"
+	}
+
+	return ""
+}
+
+// classNames describes the character classes in error messages.
+var classNames = map[string]string{
+	ClassLower:  "a lowercase letter",
+	ClassUpper:  "an uppercase letter",
+	ClassDigit:  "a digit",
+	ClassSymbol: "a symbol",
+}
+
+// hasClass reports whether password contains a character of class.
+func hasClass(password, class string) bool {
+	for _, r := range password {
+		switch {
+		case class == ClassLower && unicode.IsLower(r),
+			class == ClassUpper && unicode.IsUpper(r),
+			class == ClassDigit && unicode.IsDigit(r),
+			class == ClassSymbol && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r):
+			return true
+		}
+	}
+	return false
+}
+
+// joinWords joins words as "a, b and c".
+func joinWords(words []string) string {
+	if len(words) == 1 {
+		return words[0]
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
+
+// CheckEmail returns why email breaks the policy, or "" if it does not.
+func (p *Policy) CheckEmail(email string) string {
+	domain, ok := emailDomain(email)
+	if !ok {
+		return "A valid email is required"
+	}
+
+	if len(p.AllowedEmailDomains) > 0 && !matchesDomain(domain, p.AllowedEmailDomains) {
+		return "Email domain is not allowed"
+	}
+	if matchesDomain(domain, p.DeniedEmailDomains) {
+		return "Email domain is not allowed"
+	}
+	if p.BlockDisposableEmails && isDisposable(domain) {
+		return "Disposable email addresses are not allowed"
+	}
+
+	return ""
+}
+
+// emailDomain returns the lowercased domain of a plain address such as
+// user@example.com, rejecting display names and dotless domains.
+func emailDomain(email string) (string, bool) {
+	if len(email) > maxEmailLength {
+		return "", false
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return "", false
+	}
+
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", false
+	}
+	return domain, true
+}
+
+// matchesDomain reports whether domain is one of domains or a subdomain of
+// one.
+func matchesDomain(domain string, domains []string) bool {
+	for _, d := range domains {
+		d = strings.ToLower(d)
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDisposable reports whether domain, or a domain it is under, is on the
+// disposable list.
+func isDisposable(domain string) bool {
+	for {
+		if disposableDomains[domain] {
+			return true
+		}
+		i := strings.Index(domain, ".")
+		if i < 0 {
+			return false
+		}
+		domain = domain[i+1:]
+	}
+}
+
+// CheckInviteCode returns why code does not allow registering, or "" if
+// it does.
+func (p *Policy) CheckInviteCode(code string) string {
+	if !p.InviteOnly {
+		return ""
+	}
+	if code == "" {
+		return "An invite code is required"
+	}
+	for _, valid := range p.InviteCodes {
+		if subtle.ConstantTimeCompare([]byte(code), []byte(valid)) == 1 {
+			return ""
+		}
+	}
+	return "Invite code is invalid"
+}
+
+// CheckRegistration returns the field errors of a registration request.
+func (p *Policy) CheckRegistration(req RegisterRequest) map[string]string {
+	errors := make(map[string]string)
+	if req.Email == "" {
+		errors["email"] = "Email is required"
+	} else if msg := p.CheckEmail(req.Email); msg != "" {
+		errors["email"] = msg
+	}
+	if req.Password == "" {
+		errors["password"] = "Password is required"
+	} else if msg := p.CheckPassword(req.Password); msg != "" {
+		errors["password"] = msg
+	}
+	if msg := p.CheckInviteCode(req.InviteCode); msg != "" {
+		errors["invite_code"] = msg
+	}
+	return errors
+}
@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+)
+
+// Request headers the app sends to describe the device at sign-in.
+const (
+	HeaderDeviceName = "X-Device-Name"
+	HeaderPlatform   = "X-Platform"
+	HeaderAppVersion = "X-App-Version"
+)
+
+// maxDeviceFieldLength bounds client supplied device descriptions.
+const maxDeviceFieldLength = 128
+
+// sessionRetention is how long sessions without activity, and revoked
+// session IDs, are remembered.
+const sessionRetention = 30 * 24 * time.Hour
+
+// Session is a signed-in device, identified by the GoTrue session ID of
+// its tokens.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"-"`
+	DeviceName string    `json:"device_name,omitempty"`
+	Platform   string    `json:"platform,omitempty"`
+	AppVersion string    `json:"app_version,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// SessionStore is the registry of signed-in devices. Revoked sessions are
+// remembered so their refresh tokens can be refused.
+type SessionStore interface {
+	// Touch records activity of s, adding it if it is new.
+	Touch(ctx context.Context, s Session) error
+	// List returns the active sessions of userID, most recent first.
+	List(ctx context.Context, userID string) ([]Session, error)
+	// Revoke removes a session of userID and marks it revoked. It reports
+	// whether the session was found.
+	Revoke(ctx context.Context, userID, sessionID string) (bool, error)
+	// RevokeOthers revokes every session of userID except keepID, which
+	// may be empty to revoke them all.
+	RevokeOthers(ctx context.Context, userID, keepID string) error
+	// IsRevoked reports whether sessionID was revoked.
+	IsRevoked(ctx context.Context, sessionID string) (bool, error)
+}
+
+// tokenClaims are the claims of an access token that identify its session.
+type tokenClaims struct {
+	Sub       string `json:"sub"`
+	SessionID string `json:"session_id"`
+	// AMR lists how the session was authenticated.
+	AMR []struct {
+		Method string `json:"method"`
+	} `json:"amr"`
+	jwt.RegisteredClaims
+}
+
+// authenticatedBy reports whether the session was authenticated with any of
+// methods, e.g. "password", "otp" or "recovery".
+func (c *tokenClaims) authenticatedBy(methods ...string) bool {
+	for _, amr := range c.AMR {
+		for _, method := range methods {
+			if amr.Method == method {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseTokenClaims reads the claims of accessToken without verifying it.
+// Callers only use it for tokens GoTrue just issued or accepted. ok is false
+// for tokens without a session ID.
+func parseTokenClaims(accessToken string) (*tokenClaims, bool) {
+	var claims tokenClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(accessToken, &claims); err != nil || claims.SessionID == "" {
+		return nil, false
+	}
+	return &claims, true
+}
+
+// sessionFromRequest describes the session of accessToken, as seen on the
+// request that created or refreshed it. ok is false for tokens without a
+// session ID.
+func sessionFromRequest(c echo.Context, accessToken string) (Session, bool) {
+	claims, ok := parseTokenClaims(accessToken)
+	if !ok {
+		return Session{}, false
+	}
+
+	req := c.Request()
+	now := time.Now().UTC()
+	return Session{
+		ID:         claims.SessionID,
+		UserID:     claims.Sub,
+		DeviceName: truncate(req.Header.Get(HeaderDeviceName)),
+		Platform:   truncate(req.Header.Get(HeaderPlatform)),
+		AppVersion: truncate(req.Header.Get(HeaderAppVersion)),
+		UserAgent:  truncate(req.UserAgent()),
+		IP:         c.RealIP(),
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}, true
+}
+
+// TrackSession records in sessions the device that the session of resp was
+// created or refreshed from, for handlers outside this package that issue
+// sessions.
+func TrackSession(c echo.Context, sessions SessionStore, resp *supabase.AuthResponse) {
+	if session, ok := sessionFromRequest(c, resp.AccessToken); ok {
+		_ = sessions.Touch(c.Request().Context(), session)
+	}
+}
+
+func truncate(s string) string {
+	if len(s) > maxDeviceFieldLength {
+		return s[:maxDeviceFieldLength]
+	}
+	return s
+}
+
+// MemorySessionStore is an in-process SessionStore. Sessions are lost on
+// restart and not shared between instances.
+type MemorySessionStore struct {
+	mu sync.Mutex
+	// sessions maps user IDs to their sessions by ID.
+	sessions map[string]map[string]*Session
+	// revoked maps revoked session IDs to when they were revoked.
+	revoked   map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemorySessionStore creates an empty in-memory store.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string]map[string]*Session),
+		revoked:  make(map[string]time.Time),
+	}
+}
+
+// Touch implements SessionStore.
+func (s *MemorySessionStore) Touch(_ context.Context, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(time.Now())
+
+	if _, ok := s.revoked[session.ID]; ok {
+		return nil
+	}
+
+	userSessions := s.sessions[session.UserID]
+	if userSessions == nil {
+		userSessions = make(map[string]*Session)
+		s.sessions[session.UserID] = userSessions
+	}
+	if existing, ok := userSessions[session.ID]; ok {
+		session.CreatedAt = existing.CreatedAt
+	}
+	userSessions[session.ID] = &session
+	return nil
+}
+
+// List implements SessionStore.
+func (s *MemorySessionStore) List(_ context.Context, userID string) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := make([]Session, 0, len(s.sessions[userID]))
+	for _, session := range s.sessions[userID] {
+		sessions = append(sessions, *session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// Revoke implements SessionStore.
+func (s *MemorySessionStore) Revoke(_ context.Context, userID, sessionID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[userID][sessionID]; !ok {
+		return false, nil
+	}
+	s.revoke(userID, sessionID)
+	return true, nil
+}
+
+// RevokeOthers implements SessionStore.
+func (s *MemorySessionStore) RevokeOthers(_ context.Context, userID, keepID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.sessions[userID] {
+		if id != keepID {
+			s.revoke(userID, id)
+		}
+	}
+	return nil
+}
+
+// IsRevoked implements SessionStore.
+func (s *MemorySessionStore) IsRevoked(_ context.Context, sessionID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.revoked[sessionID]
+	return ok, nil
+}
+
+// revoke removes a session and remembers its ID. Callers hold s.mu.
+func (s *MemorySessionStore) revoke(userID, sessionID string) {
+	delete(s.sessions[userID], sessionID)
+	if len(s.sessions[userID]) == 0 {
+		delete(s.sessions, userID)
+	}
+	s.revoked[sessionID] = time.Now()
+}
+
+// sweep forgets idle sessions and old revocations at most once a minute.
+// Callers hold s.mu.
+func (s *MemorySessionStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	cutoff := now.Add(-sessionRetention)
+	for userID, userSessions := range s.sessions {
+		for id, session := range userSessions {
+			if session.LastSeenAt.Before(cutoff) {
+				delete(userSessions, id)
+			}
+		}
+		if len(userSessions) == 0 {
+			delete(s.sessions, userID)
+		}
+	}
+	for id, revokedAt := range s.revoked {
+		if revokedAt.Before(cutoff) {
+			delete(s.revoked, id)
+		}
+	}
+}
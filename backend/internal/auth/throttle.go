@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attempts is the failed sign-in record of one email or IP address.
+type Attempts struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+// AttemptStore records failed sign-in attempts, keyed by email or IP
+// address. Implementations shared between instances must update records
+// atomically.
+type AttemptStore interface {
+	// Fail records a failure for key and returns the record as it was
+	// before. Failures are forgotten once window has passed since the last
+	// one.
+	Fail(ctx context.Context, key string, window time.Duration) (Attempts, error)
+	// Forgive removes one failure of key, for an attempt that was counted
+	// as failed in advance but did not fail.
+	Forgive(ctx context.Context, key string) error
+	// Reset forgets the failures of key.
+	Reset(ctx context.Context, key string) error
+}
+
+// LoginThrottle slows down and locks out repeated failed password sign-ins.
+// After DelayAfter failures each further attempt must wait BaseDelay,
+// doubling with every failure up to MaxDelay. After EmailLockout failures
+// for an email, or IPLockout failures from an IP address, sign-in is
+// refused for LockoutDuration. Delays apply to emails only, so that users
+// sharing an address are not slowed down by each other. A zero BaseDelay
+// or lockout threshold disables that check.
+//
+// Every attempt is counted as failed before it is made, so that concurrent
+// attempts cannot all pass the check; attempts refused while throttled
+// count too, which extends the wait.
+type LoginThrottle struct {
+	// Store holds the failure counts. Defaults to an in-memory store.
+	Store AttemptStore
+
+	// Window is how long failures are remembered after the last one. It
+	// must be at least LockoutDuration.
+	Window time.Duration
+
+	DelayAfter int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+
+	EmailLockout    int
+	IPLockout       int
+	LockoutDuration time.Duration
+}
+
+// throttleKeys returns the store keys of a sign-in attempt.
+func throttleKeys(email, ip string) (emailKey, ipKey string) {
+	return "email:" + strings.ToLower(strings.TrimSpace(email)), "ip:" + ip
+}
+
+// Attempt counts a sign-in for email from ip as failed and returns how long
+// the caller must wait before retrying, or zero if the sign-in may proceed.
+// Call Succeeded or Aborted once a sign-in that may proceed did not fail.
+func (t *LoginThrottle) Attempt(ctx context.Context, email, ip string) (time.Duration, error) {
+	emailKey, ipKey := throttleKeys(email, ip)
+	now := time.Now()
+
+	byEmail, err := t.Store.Fail(ctx, emailKey, t.Window)
+	if err != nil {
+		return 0, err
+	}
+	byIP, err := t.Store.Fail(ctx, ipKey, t.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	if t.wait(byEmail, t.EmailLockout, true, now) == 0 && t.wait(byIP, t.IPLockout, false, now) == 0 {
+		return 0, nil
+	}
+
+	// Refused: report the wait that this attempt, now counted, adds.
+	byEmail = Attempts{Failures: byEmail.Failures + 1, LastFailure: now}
+	byIP = Attempts{Failures: byIP.Failures + 1, LastFailure: now}
+	wait := t.wait(byEmail, t.EmailLockout, true, now)
+	if w := t.wait(byIP, t.IPLockout, false, now); w > wait {
+		wait = w
+	}
+	return wait, nil
+}
+
+// Succeeded forgets the failures of email and takes back the attempt from
+// ip. Earlier failures from the IP address are kept, so that signing in to
+// one account does not reset an attack on others.
+func (t *LoginThrottle) Succeeded(ctx context.Context, email, ip string) error {
+	emailKey, ipKey := throttleKeys(email, ip)
+	if err := t.Store.Reset(ctx, emailKey); err != nil {
+		return err
+	}
+	return t.Store.Forgive(ctx, ipKey)
+}
+
+// Aborted takes back an attempt that could not be made, such as when the
+// auth service is unavailable.
+func (t *LoginThrottle) Aborted(ctx context.Context, email, ip string) error {
+	emailKey, ipKey := throttleKeys(email, ip)
+	if err := t.Store.Forgive(ctx, emailKey); err != nil {
+		return err
+	}
+	return t.Store.Forgive(ctx, ipKey)
+}
+
+// wait returns how long after now the next attempt for a record is allowed.
+func (t *LoginThrottle) wait(a Attempts, lockout int, delays bool, now time.Time) time.Duration {
+	if a.Failures == 0 {
+		return 0
+	}
+
+	var until time.Time
+	switch {
+	case lockout > 0 && a.Failures >= lockout:
+		until = a.LastFailure.Add(t.LockoutDuration)
+	case delays && t.BaseDelay > 0 && a.Failures >= t.DelayAfter:
+		until = a.LastFailure.Add(t.delay(a.Failures - t.DelayAfter))
+	default:
+		return 0
+	}
+
+	if wait := until.Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// delay returns BaseDelay doubled n times, capped at MaxDelay.
+func (t *LoginThrottle) delay(n int) time.Duration {
+	d := t.BaseDelay
+	for i := 0; i < n; i++ {
+		if t.MaxDelay > 0 && d >= t.MaxDelay {
+			break
+		}
+		d *= 2
+	}
+	if t.MaxDelay > 0 && d > t.MaxDelay {
+		d = t.MaxDelay
+	}
+	return d
+}
+
+// attemptEntry is a stored failure record.
+type attemptEntry struct {
+	attempts  Attempts
+	expiresAt time.Time
+}
+
+// MemoryAttemptStore is an in-process AttemptStore. Failure counts are not
+// shared between instances, so each instance enforces the limits on its
+// own.
+type MemoryAttemptStore struct {
+	mu        sync.Mutex
+	entries   map[string]attemptEntry
+	lastSweep time.Time
+}
+
+// NewMemoryAttemptStore creates an empty in-memory store.
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{entries: make(map[string]attemptEntry)}
+}
+
+// Fail implements AttemptStore.
+func (s *MemoryAttemptStore) Fail(_ context.Context, key string, window time.Duration) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		entry = attemptEntry{}
+	}
+	before := entry.attempts
+	entry.attempts.Failures++
+	entry.attempts.LastFailure = now
+	entry.expiresAt = now.Add(window)
+	s.entries[key] = entry
+
+	return before, nil
+}
+
+// Forgive implements AttemptStore.
+func (s *MemoryAttemptStore) Forgive(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	entry.attempts.Failures--
+	if entry.attempts.Failures <= 0 || !time.Now().Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil
+	}
+	s.entries[key] = entry
+	return nil
+}
+
+// Reset implements AttemptStore.
+func (s *MemoryAttemptStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// sweep drops expired entries at most once a minute. Callers hold s.mu.
+func (s *MemoryAttemptStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
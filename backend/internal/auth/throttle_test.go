@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	type step struct {
+		// sleep passes before the attempt.
+		sleep     time.Duration
+		email, ip string
+		// succeed signs in successfully if the attempt is not throttled.
+		succeed   bool
+		throttled bool
+	}
+
+	tests := []struct {
+		name     string
+		throttle LoginThrottle
+		steps    []step
+	}{
+		{
+			name:     "repeated failures on one email",
+			throttle: LoginThrottle{EmailLockout: 3, LockoutDuration: time.Minute},
+			steps: []step{
+				{email: "a@example.com", ip: "10.0.0.1"},
+				{email: "a@example.com", ip: "10.0.0.2"},
+				{email: "A@example.com ", ip: "10.0.0.3"},
+				{email: "a@example.com", ip: "10.0.0.4", throttled: true},
+				{email: "a@example.com", ip: "10.0.0.5", succeed: true, throttled: true},
+				{email: "b@example.com", ip: "10.0.0.1"},
+			},
+		},
+		{
+			name:     "one IP spraying many emails",
+			throttle: LoginThrottle{EmailLockout: 10, IPLockout: 3, LockoutDuration: time.Minute},
+			steps: []step{
+				{email: "a@example.com", ip: "10.0.0.1"},
+				{email: "b@example.com", ip: "10.0.0.1"},
+				{email: "c@example.com", ip: "10.0.0.1"},
+				{email: "d@example.com", ip: "10.0.0.1", throttled: true},
+				{email: "d@example.com", ip: "10.0.0.2"},
+			},
+		},
+		{
+			name:     "delay after failures",
+			throttle: LoginThrottle{DelayAfter: 2, BaseDelay: time.Minute, IPLockout: 2, LockoutDuration: time.Minute},
+			steps: []step{
+				{email: "a@example.com", ip: "10.0.0.1"},
+				{email: "a@example.com", ip: "10.0.0.2"},
+				{email: "a@example.com", ip: "10.0.0.3", throttled: true},
+				// Delays do not apply to IP addresses.
+				{email: "b@example.com", ip: "10.0.0.4"},
+			},
+		},
+		{
+			name:     "lockout expires",
+			throttle: LoginThrottle{EmailLockout: 2, LockoutDuration: 50 * time.Millisecond},
+			steps: []step{
+				{email: "a@example.com", ip: "10.0.0.1"},
+				{email: "a@example.com", ip: "10.0.0.1"},
+				{email: "a@example.com", ip: "10.0.0.1", throttled: true},
+				{sleep: 60 * time.Millisecond, email: "a@example.com", ip: "10.0.0.1"},
+			},
+		},
+		{
+			name:     "failures expire after the window",
+			throttle: LoginThrottle{Window: 50 * time.Millisecond, EmailLockout: 2, LockoutDuration: time.Minute},
+			steps: []step{
+				{email: "a@example.com", ip: "10.0.0.1"},
+				{sleep: 60 * time.Millisecond, email: "a@example.com", ip: "10.0.0.1"},
+				{email: "a@example.com", ip: "10.0.0.1"},
+				{email: "a@example.com", ip: "10.0.0.1", throttled: true},
+			},
+		},
+		{
+			name:     "success resets email failures",
+			throttle: LoginThrottle{EmailLockout: 3, LockoutDuration: time.Minute},
+			steps: []step{
+				{email: "a@example.com", ip: "10.0.0.1"},
+				{email: "a@example.com", ip: "10.0.0.1"},
+				{email: "a@example.com", ip: "10.0.0.1", succeed: true},
+				{email: "a@example.com", ip: "10.0.0.1"},
+				{email: "a@example.com", ip: "10.0.0.1"},
+				{email: "a@example.com", ip: "10.0.0.1"},
+				{email: "a@example.com", ip: "10.0.0.1", throttled: true},
+			},
+		},
+		{
+			name:     "success keeps IP failures",
+			throttle: LoginThrottle{EmailLockout: 10, IPLockout: 3, LockoutDuration: time.Minute},
+			steps: []step{
+				{email: "a@example.com", ip: "10.0.0.1"},
+				{email: "b@example.com", ip: "10.0.0.1"},
+				{email: "c@example.com", ip: "10.0.0.1", succeed: true},
+				{email: "d@example.com", ip: "10.0.0.1"},
+				{email: "e@example.com", ip: "10.0.0.1", throttled: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := tt.throttle
+			throttle.Store = NewMemoryAttemptStore()
+			if throttle.Window == 0 {
+				throttle.Window = time.Minute
+			}
+			ctx := context.Background()
+
+			for i, s := range tt.steps {
+				time.Sleep(s.sleep)
+
+				wait, err := throttle.Attempt(ctx, s.email, s.ip)
+				if err != nil {
+					t.Fatalf("step %d: Attempt: %v", i, err)
+				}
+				if got := wait > 0; got != s.throttled {
+					t.Fatalf("step %d: throttled = %v (wait %v), want %v", i, got, wait, s.throttled)
+				}
+				if !s.throttled && s.succeed {
+					if err := throttle.Succeeded(ctx, s.email, s.ip); err != nil {
+						t.Fatalf("step %d: Succeeded: %v", i, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLoginThrottleDelayGrowth(t *testing.T) {
+	throttle := LoginThrottle{DelayAfter: 2, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	now := time.Now()
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: 0},
+		{failures: 2, want: time.Second},
+		{failures: 3, want: 2 * time.Second},
+		{failures: 4, want: 4 * time.Second},
+		{failures: 5, want: 8 * time.Second},
+		{failures: 6, want: 10 * time.Second},
+		{failures: 100, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		got := throttle.wait(Attempts{Failures: tt.failures, LastFailure: now}, 0, true, now)
+		if got != tt.want {
+			t.Errorf("wait after %d failures = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottleRetryAfterGrows(t *testing.T) {
+	throttle := LoginThrottle{
+		Store:      NewMemoryAttemptStore(),
+		Window:     time.Hour,
+		DelayAfter: 1,
+		BaseDelay:  time.Second,
+		MaxDelay:   4 * time.Second,
+	}
+	ctx := context.Background()
+
+	if wait, _ := throttle.Attempt(ctx, "a@example.com", "10.0.0.1"); wait != 0 {
+		t.Fatalf("first attempt waits %v", wait)
+	}
+
+	// Each refused attempt counts, doubling the wait up to MaxDelay.
+	for _, want := range []time.Duration{2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		wait, err := throttle.Attempt(ctx, "a@example.com", "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait != want {
+			t.Errorf("wait = %v, want %v", wait, want)
+		}
+	}
+}
+
+func TestLoginThrottleConcurrentAttempts(t *testing.T) {
+	throttle := LoginThrottle{
+		Store:           NewMemoryAttemptStore(),
+		Window:          time.Minute,
+		EmailLockout:    5,
+		LockoutDuration: time.Minute,
+	}
+	ctx := context.Background()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait, err := throttle.Attempt(ctx, "a@example.com", "10.0.0.1")
+			if err == nil && wait == 0 {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 5 {
+		t.Errorf("%d concurrent attempts allowed, want 5", allowed)
+	}
+}
@@ -6,7 +6,9 @@ import "time"
 // RegisterRequest represents a user registration request.
 type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Password string `json:"password" validate:"required"`
+	// InviteCode is required when registration is invite-only.
+	InviteCode string `json:"invite_code,omitempty"`
 }
 
 // LoginRequest represents a user login request.
@@ -20,11 +22,82 @@ type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
+// OTPRequest represents a passwordless sign-in request.
+type OTPRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	// RedirectTo overrides where the magic link leads; it must be one of
+	// the allowed redirect URLs.
+	RedirectTo string `json:"redirect_to,omitempty"`
+}
+
+// VerifyRequest represents a one-time password verification, either an
+// emailed code with its email or the token hash of a magic link.
+type VerifyRequest struct {
+	Email     string `json:"email,omitempty" validate:"required_with=Token,omitempty,email"`
+	Token     string `json:"token,omitempty" validate:"required_without=TokenHash,excluded_with=TokenHash"`
+	TokenHash string `json:"token_hash,omitempty"`
+	// Type is the kind of email the code came from; defaults to "email".
+	Type string `json:"type,omitempty" validate:"omitempty,oneof=email magiclink signup invite"`
+}
+
+// OAuthStartRequest represents the start of an OAuth sign-in.
+type OAuthStartRequest struct {
+	// Provider is the provider to sign in with, from the URL path.
+	Provider string `param:"provider" json:"-" validate:"required"`
+	// RedirectTo overrides where the browser is sent after signing in; it
+	// must be one of the allowed redirect URLs.
+	RedirectTo string `json:"redirect_to,omitempty"`
+}
+
+// OAuthStartResponse holds the URL the app opens to sign in with a
+// provider, and the state to complete the sign-in with.
+type OAuthStartResponse struct {
+	URL       string `json:"url"`
+	State     string `json:"state"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// OAuthCallbackRequest represents the completion of an OAuth sign-in with
+// the code from the redirect URL.
+type OAuthCallbackRequest struct {
+	State string `json:"state" validate:"required"`
+	Code  string `json:"code" validate:"required"`
+}
+
+// RecoverRequest represents a password recovery request.
+type RecoverRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	// RedirectTo overrides where the recovery link leads; it must be one of
+	// the allowed redirect URLs.
+	RedirectTo string `json:"redirect_to,omitempty"`
+}
+
+// ResetPasswordRequest represents a password reset from a recovery link,
+// identified by either its token hash or the access token of the session
+// it signed in. Access tokens of other sessions are rejected.
+type ResetPasswordRequest struct {
+	AccessToken string `json:"access_token,omitempty"`
+	TokenHash   string `json:"token_hash,omitempty"`
+	Password    string `json:"password" validate:"required"`
+}
+
+// User metadata keys holding the profile fields of User.
+const (
+	MetadataDisplayName = "display_name"
+	MetadataAvatarURL   = "avatar_url"
+	MetadataLocale      = "locale"
+	MetadataTimezone    = "timezone"
+)
+
 // User represents an authenticated user.
 type User struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          string    `json:"id"`
+	Email       string    `json:"email"`
+	DisplayName string    `json:"display_name,omitempty"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	Locale      string    `json:"locale,omitempty"`
+	Timezone    string    `json:"timezone,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 // AuthResponse represents the authentication response with tokens.
@@ -37,6 +110,7 @@ type AuthResponse struct {
 
 // ErrorResponse represents an error response.
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+	Error   string            `json:"error"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
 }
@@ -2,17 +2,136 @@
 package config
 
 import (
+	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Port             string
-	SupabaseURL      string
-	SupabaseKey      string
+	Port              string
+	SupabaseURL       string
+	SupabaseKey       string
 	SupabaseJWTSecret string
+
+	// SupabaseServiceKey is the service role key used for the GoTrue admin
+	// API. The admin user endpoints are disabled when it is empty.
+	SupabaseServiceKey string
+
+	// AuthRecoveryRedirectURL is where password recovery emails lead,
+	// typically an app deep link. AuthAllowedRedirectURLs lists the other
+	// URLs clients may ask email links to lead to.
+	AuthRecoveryRedirectURL string
+	AuthAllowedRedirectURLs []string
+
+	// AuthMagicLinkRedirectURL is where magic link emails lead;
+	// AuthOTPCreateUser lets passwordless sign-in create new accounts.
+	AuthMagicLinkRedirectURL string
+	AuthOTPCreateUser        bool
+
+	// AuthOAuthProviders lists the OAuth providers users may sign in with;
+	// AuthOAuthRedirectURL is where the browser returns after signing in.
+	AuthOAuthProviders   []string
+	AuthOAuthRedirectURL string
+
+	// SupabaseJWKSURL is where the public keys for asymmetrically signed
+	// tokens are fetched; SupabaseJWKSFile is a local JWKS document used
+	// when the URL is empty or unreachable.
+	SupabaseJWKSURL  string
+	SupabaseJWKSFile string
+
+	// JWTIssuers, JWTAudiences and JWTAllowedRoles are the accepted iss,
+	// aud and role claim values ("*" in the environment accepts any); JWTLeeway is the clock
+	// skew tolerated on exp/nbf/iat; JWTRequiredClaims must be present in
+	// every token.
+	JWTIssuers        []string
+	JWTAudiences      []string
+	JWTAllowedRoles   []string
+	JWTLeeway         time.Duration
+	JWTRequiredClaims []string
+
+	// MFAIssuer is the name authenticator apps show for TOTP factors;
+	// MFARequireAdmin makes the admin API require an aal2 session.
+	MFAIssuer       string
+	MFARequireAdmin bool
+
+	// TokenRevocationTTL is how long revoked sessions and users are
+	// remembered; it must be at least the Supabase access token lifetime.
+	// TokenRevocationMaxEntries bounds the in-memory revocation list.
+	TokenRevocationTTL        time.Duration
+	TokenRevocationMaxEntries int
+
+	// AuthLogin* configure brute-force protection of password sign-in:
+	// failures are remembered for AuthLoginWindow after the last one; after
+	// AuthLoginDelayAfter failures an email must wait AuthLoginDelayBase,
+	// doubling up to AuthLoginDelayMax; after AuthLoginEmailLockout failures
+	// for an email or AuthLoginIPLockout from an IP, sign-in is refused for
+	// AuthLoginLockoutDuration. Zero disables delays or a lockout.
+	AuthLoginWindow          time.Duration
+	AuthLoginDelayAfter      int
+	AuthLoginDelayBase       time.Duration
+	AuthLoginDelayMax        time.Duration
+	AuthLoginEmailLockout    int
+	AuthLoginIPLockout       int
+	AuthLoginLockoutDuration time.Duration
+
+	// AuthPassword* and AuthEmail* are the password and registration
+	// policy: minimum length, required character classes (lower, upper,
+	// digit, symbol), rejection of common breached passwords, email domain
+	// allow and deny lists, and blocking of disposable email domains.
+	// AuthInviteOnly requires one of AuthInviteCodes to register.
+	AuthPasswordMinLength       int
+	AuthPasswordRequiredClasses []string
+	AuthPasswordRejectBreached  bool
+	AuthEmailAllowedDomains     []string
+	AuthEmailDeniedDomains      []string
+	AuthEmailBlockDisposable    bool
+	AuthInviteOnly              bool
+	AuthInviteCodes             []string
+
+	// RequestTimeout bounds how long a single API request (including its
+	// upstream Supabase calls) may run before its context is cancelled.
+	RequestTimeout time.Duration
+
+	// TrustedProxies are the addresses of reverse proxies whose
+	// X-Forwarded-For header is trusted for the client IP. When empty, the
+	// client IP is the address of the connection and forwarding headers are
+	// ignored.
+	TrustedProxies []*net.IPNet
+
+	// SupabaseMaxRetries is how many times idempotent Supabase calls are
+	// retried after a transient failure (0 disables retries).
+	SupabaseMaxRetries     int
+	SupabaseRetryBaseDelay time.Duration
+	SupabaseRetryMaxDelay  time.Duration
+
+	// SupabaseBreakerThreshold is the number of consecutive failures that
+	// opens the circuit breaker (0 disables it); SupabaseBreakerCooldown is
+	// how long it stays open before probing again.
+	SupabaseBreakerThreshold int
+	SupabaseBreakerCooldown  time.Duration
+
+	// SyncConflictPolicy is how pushed offline mutations are resolved when
+	// the item changed on the server: server_wins, client_wins or merge.
+	SyncConflictPolicy string
+
+	// IdempotencyStore selects where Idempotency-Key responses are kept:
+	// "memory" (per instance) or "postgrest" (the idempotency_keys table,
+	// accessed with SupabaseServiceKey).
+	IdempotencyStore string
+	IdempotencyTTL   time.Duration
+
+	// TrashRetention is how long trashed items are kept before they are
+	// purged (0 disables purging); TrashPurgeInterval is how often the
+	// purge runs. Purging needs SupabaseServiceKey, since it deletes the
+	// items of every user.
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
 }
 
 // Load reads configuration from environment variables
@@ -20,11 +139,255 @@ func Load() (*Config, error) {
 	// Load .env file if it exists (ignore error if not found)
 	_ = godotenv.Load()
 
+	requestTimeout, err := getEnvDuration("REQUEST_TIMEOUT", 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if requestTimeout <= 0 {
+		return nil, fmt.Errorf("REQUEST_TIMEOUT must be positive")
+	}
+
+	maxRetries, err := getEnvInt("SUPABASE_MAX_RETRIES", 2)
+	if err != nil {
+		return nil, err
+	}
+	retryBaseDelay, err := getEnvDuration("SUPABASE_RETRY_BASE_DELAY", 100*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	retryMaxDelay, err := getEnvDuration("SUPABASE_RETRY_MAX_DELAY", 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	breakerThreshold, err := getEnvInt("SUPABASE_BREAKER_THRESHOLD", 5)
+	if err != nil {
+		return nil, err
+	}
+	breakerCooldown, err := getEnvDuration("SUPABASE_BREAKER_COOLDOWN", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	syncConflictPolicy := getEnv("SYNC_CONFLICT_POLICY", "server_wins")
+	switch syncConflictPolicy {
+	case "server_wins", "client_wins", "merge":
+	default:
+		return nil, fmt.Errorf("invalid SYNC_CONFLICT_POLICY: %q", syncConflictPolicy)
+	}
+
+	serviceKey := getEnv("SUPABASE_SERVICE_ROLE_KEY", "")
+
+	idempotencyStore := getEnv("IDEMPOTENCY_STORE", "memory")
+	if idempotencyStore != "memory" && idempotencyStore != "postgrest" {
+		return nil, fmt.Errorf("invalid IDEMPOTENCY_STORE: %q", idempotencyStore)
+	}
+	if idempotencyStore == "postgrest" && serviceKey == "" {
+		return nil, fmt.Errorf("IDEMPOTENCY_STORE=postgrest requires SUPABASE_SERVICE_ROLE_KEY")
+	}
+	idempotencyTTL, err := getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if idempotencyTTL <= 0 {
+		return nil, fmt.Errorf("IDEMPOTENCY_TTL must be positive")
+	}
+
+	trashRetention, err := getEnvDuration("TRASH_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	trashPurgeInterval, err := getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if trashRetention > 0 && trashPurgeInterval <= 0 {
+		return nil, fmt.Errorf("TRASH_PURGE_INTERVAL must be positive")
+	}
+
+	supabaseURL := getEnv("SUPABASE_URL", "")
+	jwksURL := getEnv("SUPABASE_JWKS_URL", "")
+	if jwksURL == "" && supabaseURL != "" {
+		jwksURL = strings.TrimSuffix(supabaseURL, "/") + "/auth/v1/.well-known/jwks.json"
+	}
+
+	otpCreateUser, err := getEnvBool("AUTH_OTP_CREATE_USER", false)
+	if err != nil {
+		return nil, err
+	}
+
+	mfaRequireAdmin, err := getEnvBool("MFA_REQUIRE_ADMIN", false)
+	if err != nil {
+		return nil, err
+	}
+
+	revocationTTL, err := getEnvDuration("TOKEN_REVOCATION_TTL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if revocationTTL <= 0 {
+		return nil, fmt.Errorf("TOKEN_REVOCATION_TTL must be positive")
+	}
+	revocationMaxEntries, err := getEnvInt("TOKEN_REVOCATION_MAX_ENTRIES", 100000)
+	if err != nil {
+		return nil, err
+	}
+	if revocationMaxEntries <= 0 {
+		return nil, fmt.Errorf("TOKEN_REVOCATION_MAX_ENTRIES must be positive")
+	}
+
+	loginWindow, err := getEnvDuration("AUTH_LOGIN_WINDOW", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	loginDelayAfter, err := getEnvInt("AUTH_LOGIN_DELAY_AFTER", 3)
+	if err != nil {
+		return nil, err
+	}
+	loginDelayBase, err := getEnvDuration("AUTH_LOGIN_DELAY_BASE", time.Second)
+	if err != nil {
+		return nil, err
+	}
+	loginDelayMax, err := getEnvDuration("AUTH_LOGIN_DELAY_MAX", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	loginEmailLockout, err := getEnvInt("AUTH_LOGIN_EMAIL_LOCKOUT", 10)
+	if err != nil {
+		return nil, err
+	}
+	loginIPLockout, err := getEnvInt("AUTH_LOGIN_IP_LOCKOUT", 50)
+	if err != nil {
+		return nil, err
+	}
+	loginLockoutDuration, err := getEnvDuration("AUTH_LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if loginWindow <= 0 {
+		return nil, fmt.Errorf("AUTH_LOGIN_WINDOW must be positive")
+	}
+	if loginDelayAfter < 0 || loginEmailLockout < 0 || loginIPLockout < 0 {
+		return nil, fmt.Errorf("AUTH_LOGIN_DELAY_AFTER and lockout thresholds must not be negative")
+	}
+	if loginDelayBase < 0 || loginDelayMax < 0 || loginLockoutDuration < 0 {
+		return nil, fmt.Errorf("AUTH_LOGIN delays and lockout duration must not be negative")
+	}
+	if loginLockoutDuration > loginWindow {
+		return nil, fmt.Errorf("AUTH_LOGIN_LOCKOUT_DURATION must not exceed AUTH_LOGIN_WINDOW")
+	}
+
+	passwordMinLength, err := getEnvInt("AUTH_PASSWORD_MIN_LENGTH", 8)
+	if err != nil {
+		return nil, err
+	}
+	if passwordMinLength < 6 || passwordMinLength > 72 {
+		return nil, fmt.Errorf("AUTH_PASSWORD_MIN_LENGTH must be between 6 and 72")
+	}
+	passwordClasses := getEnvList("AUTH_PASSWORD_REQUIRED_CLASSES", "")
+	for _, class := range passwordClasses {
+		switch class {
+		case "lower", "upper", "digit", "symbol":
+		default:
+			return nil, fmt.Errorf("AUTH_PASSWORD_REQUIRED_CLASSES must contain only lower, upper, digit or symbol")
+		}
+	}
+	passwordRejectBreached, err := getEnvBool("AUTH_PASSWORD_REJECT_BREACHED", true)
+	if err != nil {
+		return nil, err
+	}
+	emailBlockDisposable, err := getEnvBool("AUTH_EMAIL_BLOCK_DISPOSABLE", false)
+	if err != nil {
+		return nil, err
+	}
+	inviteOnly, err := getEnvBool("AUTH_INVITE_ONLY", false)
+	if err != nil {
+		return nil, err
+	}
+	inviteCodes := getEnvList("AUTH_INVITE_CODES", "")
+	if inviteOnly && len(inviteCodes) == 0 {
+		return nil, fmt.Errorf("AUTH_INVITE_CODES is required when AUTH_INVITE_ONLY is set")
+	}
+
+	trustedProxies, err := parseIPNets(getEnvList("TRUSTED_PROXIES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+
+	defaultIssuers := ""
+	if supabaseURL != "" {
+		defaultIssuers = strings.TrimSuffix(supabaseURL, "/") + "/auth/v1"
+	}
+	jwtLeeway, err := getEnvDuration("JWT_LEEWAY", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if jwtLeeway < 0 {
+		return nil, fmt.Errorf("JWT_LEEWAY must not be negative")
+	}
+
 	cfg := &Config{
-		Port:             getEnv("PORT", "8080"),
-		SupabaseURL:      getEnv("SUPABASE_URL", ""),
-		SupabaseKey:      getEnv("SUPABASE_KEY", ""),
+		Port:              getEnv("PORT", "8080"),
+		SupabaseURL:       supabaseURL,
+		SupabaseKey:       getEnv("SUPABASE_KEY", ""),
 		SupabaseJWTSecret: getEnv("SUPABASE_JWT_SECRET", ""),
+		SupabaseJWKSURL:   jwksURL,
+		SupabaseJWKSFile:  getEnv("SUPABASE_JWKS_FILE", ""),
+		RequestTimeout:    requestTimeout,
+		TrustedProxies:    trustedProxies,
+
+		SupabaseServiceKey: serviceKey,
+
+		AuthRecoveryRedirectURL: getEnv("AUTH_RECOVERY_REDIRECT_URL", ""),
+		AuthAllowedRedirectURLs: getEnvList("AUTH_ALLOWED_REDIRECT_URLS", ""),
+
+		AuthMagicLinkRedirectURL: getEnv("AUTH_MAGIC_LINK_REDIRECT_URL", ""),
+		AuthOTPCreateUser:        otpCreateUser,
+
+		AuthOAuthProviders:   getEnvList("AUTH_OAUTH_PROVIDERS", ""),
+		AuthOAuthRedirectURL: getEnv("AUTH_OAUTH_REDIRECT_URL", ""),
+
+		AuthLoginWindow:          loginWindow,
+		AuthLoginDelayAfter:      loginDelayAfter,
+		AuthLoginDelayBase:       loginDelayBase,
+		AuthLoginDelayMax:        loginDelayMax,
+		AuthLoginEmailLockout:    loginEmailLockout,
+		AuthLoginIPLockout:       loginIPLockout,
+		AuthLoginLockoutDuration: loginLockoutDuration,
+
+		AuthPasswordMinLength:       passwordMinLength,
+		AuthPasswordRequiredClasses: passwordClasses,
+		AuthPasswordRejectBreached:  passwordRejectBreached,
+		AuthEmailAllowedDomains:     getEnvList("AUTH_EMAIL_ALLOWED_DOMAINS", ""),
+		AuthEmailDeniedDomains:      getEnvList("AUTH_EMAIL_DENIED_DOMAINS", ""),
+		AuthEmailBlockDisposable:    emailBlockDisposable,
+		AuthInviteOnly:              inviteOnly,
+		AuthInviteCodes:             inviteCodes,
+
+		JWTIssuers:        getEnvList("JWT_ISSUERS", defaultIssuers),
+		JWTAudiences:      getEnvList("JWT_AUDIENCES", "authenticated"),
+		JWTAllowedRoles:   getEnvList("JWT_ALLOWED_ROLES", "authenticated"),
+		JWTLeeway:         jwtLeeway,
+		JWTRequiredClaims: getEnvList("JWT_REQUIRED_CLAIMS", "sub,exp"),
+
+		MFAIssuer:       getEnv("MFA_TOTP_ISSUER", ""),
+		MFARequireAdmin: mfaRequireAdmin,
+
+		TokenRevocationTTL:        revocationTTL,
+		TokenRevocationMaxEntries: revocationMaxEntries,
+
+		SupabaseMaxRetries:       maxRetries,
+		SupabaseRetryBaseDelay:   retryBaseDelay,
+		SupabaseRetryMaxDelay:    retryMaxDelay,
+		SupabaseBreakerThreshold: breakerThreshold,
+		SupabaseBreakerCooldown:  breakerCooldown,
+
+		SyncConflictPolicy: syncConflictPolicy,
+
+		IdempotencyStore: idempotencyStore,
+		IdempotencyTTL:   idempotencyTTL,
+
+		TrashRetention:     trashRetention,
+		TrashPurgeInterval: trashPurgeInterval,
 	}
 
 	return cfg, nil
@@ -37,3 +400,87 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvDuration retrieves a duration (e.g. "15s") with a default fallback
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// getEnvInt retrieves an integer environment variable with a default fallback
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// getEnvBool retrieves a boolean environment variable with a default fallback
+func getEnvBool(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+// getEnvList retrieves a comma-separated list with a default fallback. The
+// value "*" yields an empty list.
+func getEnvList(key, defaultValue string) []string {
+	value := getEnv(key, defaultValue)
+	if value == "*" {
+		return nil
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parseIPNets parses CIDR ranges and single IP addresses.
+func parseIPNets(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", value)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Application roles granted through the app_metadata claim.
+const (
+	// RoleAdmin has full access to the admin API.
+	RoleAdmin = "admin"
+	// RoleSupport has read-only access to the admin API.
+	RoleSupport = "support"
+)
+
+// AppMetadata holds the Supabase app_metadata claim. Only the service role
+// can change it, so roles and scopes set there can be trusted.
+type AppMetadata struct {
+	Provider string     `json:"provider,omitempty"`
+	Role     string     `json:"role,omitempty"`
+	Roles    stringList `json:"roles,omitempty"`
+	Scopes   stringList `json:"scopes,omitempty"`
+}
+
+// stringList is a claim holding either a single string or a list of them.
+type stringList []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = strings.Fields(single)
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// Roles returns every role the token grants: the database role claim and
+// the application roles from app_metadata.
+func (c *Claims) Roles() []string {
+	var roles []string
+	if c.Role != "" {
+		roles = append(roles, c.Role)
+	}
+	if c.AppMetadata.Role != "" {
+		roles = append(roles, c.AppMetadata.Role)
+	}
+	return append(roles, c.AppMetadata.Roles...)
+}
+
+// HasRole reports whether the token grants role.
+func (c *Claims) HasRole(role string) bool {
+	return contains(c.Roles(), role)
+}
+
+// Scopes returns the scopes from the scope claim and from app_metadata.
+func (c *Claims) Scopes() []string {
+	return append(strings.Fields(c.Scope), c.AppMetadata.Scopes...)
+}
+
+// HasScope reports whether the token grants scope.
+func (c *Claims) HasScope(scope string) bool {
+	return contains(c.Scopes(), scope)
+}
+
+// RequireRole creates a middleware that only lets through requests whose
+// token grants at least one of roles. It must run after JWTAuth.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims := GetClaims(c)
+			if claims == nil {
+				return unauthenticated(c)
+			}
+			if !containsAny(roles, claims.Roles()) {
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error":   "insufficient_role",
+					"message": "Your role does not allow this action",
+				})
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequireScope creates a middleware that only lets through requests whose
+// token grants all of scopes. It must run after JWTAuth.
+func RequireScope(scopes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims := GetClaims(c)
+			if claims == nil {
+				return unauthenticated(c)
+			}
+			for _, scope := range scopes {
+				if !claims.HasScope(scope) {
+					return c.JSON(http.StatusForbidden, map[string]string{
+						"error":   "insufficient_scope",
+						"message": "Token is missing the " + scope + " scope",
+					})
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequireRoleToWrite creates a middleware that lets every authorized role
+// read, but only roles may make mutating requests. It must run after
+// JWTAuth, typically together with RequireRole.
+func RequireRoleToWrite(roles ...string) echo.MiddlewareFunc {
+	requireRole := RequireRole(roles...)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		guarded := requireRole(next)
+		return func(c echo.Context) error {
+			if !isMutating(c.Request().Method) {
+				return next(c)
+			}
+			return guarded(c)
+		}
+	}
+}
+
+func unauthenticated(c echo.Context) error {
+	return c.JSON(http.StatusUnauthorized, map[string]string{
+		"error":   "unauthorized",
+		"message": "User not authenticated",
+	})
+}
@@ -0,0 +1,277 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client's key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the size of client supplied keys.
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored and replayed with a body.
+var replayedHeaders = []string{echo.HeaderContentType, echo.HeaderLocation, "ETag", "Link"}
+
+// ErrIdempotencyKeyInUse is returned by IdempotencyStore.Begin when another
+// request with the same key is still being processed.
+var ErrIdempotencyKeyInUse = errors.New("idempotency key in use")
+
+// IdempotencyRecord is the stored outcome of a request made with a key.
+type IdempotencyRecord struct {
+	// Fingerprint identifies the request the key was first used with.
+	Fingerprint string      `json:"fingerprint"`
+	Completed   bool        `json:"completed"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	ExpiresAt   time.Time   `json:"expires_at"`
+}
+
+// IdempotencyStore persists idempotency records.
+type IdempotencyStore interface {
+	// Begin reserves key for a request with fingerprint. If the key already
+	// holds a completed record, that record is returned instead; if it is
+	// reserved by a request still in flight, ErrIdempotencyKeyInUse is.
+	Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error)
+	// Complete stores the response for a reserved key.
+	Complete(ctx context.Context, key string, record IdempotencyRecord) error
+	// Release drops a reservation so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyConfig holds idempotency middleware configuration.
+type IdempotencyConfig struct {
+	Store IdempotencyStore
+	// TTL is how long a response is replayed for retries of its key.
+	TTL time.Duration
+}
+
+// Idempotency creates a middleware that makes mutating requests carrying an
+// Idempotency-Key header safe to retry. The first response for a key is
+// stored per user and replayed for later requests with the same key; reusing
+// a key with a different request is rejected. It must run after JWTAuth.
+func Idempotency(config IdempotencyConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(IdempotencyKeyHeader)
+			if key == "" || !isMutating(c.Request().Method) {
+				return next(c)
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error":   "invalid_idempotency_key",
+					"message": "Idempotency key is too long",
+				})
+			}
+
+			userID := GetUserID(c)
+			if userID == "" {
+				return next(c)
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error":   "invalid_request",
+					"message": "Invalid request body",
+				})
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := c.Request().Context()
+			storeKey := userID + ":" + key
+			fingerprint := requestFingerprint(c.Request(), body)
+
+			existing, err := config.Store.Begin(ctx, storeKey, fingerprint, config.TTL)
+			if errors.Is(err, ErrIdempotencyKeyInUse) {
+				return c.JSON(http.StatusConflict, map[string]string{
+					"error":   "idempotency_key_in_use",
+					"message": "A request with this idempotency key is already in progress",
+				})
+			}
+			if err != nil {
+				return c.JSON(http.StatusServiceUnavailable, map[string]string{
+					"error":   "service_unavailable",
+					"message": "Idempotency store unavailable",
+				})
+			}
+
+			if existing != nil {
+				if existing.Fingerprint != fingerprint {
+					return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+						"error":   "idempotency_key_reused",
+						"message": "Idempotency key was already used with a different request",
+					})
+				}
+				return replay(c, existing)
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			// Release the reservation unless the response is stored, also
+			// when next panics, so that the request can be retried.
+			storeCtx := context.WithoutCancel(ctx)
+			completed := false
+			defer func() {
+				if !completed {
+					_ = config.Store.Release(storeCtx, storeKey)
+				}
+			}()
+
+			err = next(c)
+
+			// Keep the key only for final outcomes; server errors may succeed
+			// on retry.
+			status := c.Response().Status
+			if err != nil || status >= http.StatusInternalServerError || !c.Response().Committed {
+				return err
+			}
+
+			record := IdempotencyRecord{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				Status:      status,
+				Header:      http.Header{},
+				Body:        recorder.body.Bytes(),
+				ExpiresAt:   time.Now().Add(config.TTL),
+			}
+			for _, name := range replayedHeaders {
+				if value := c.Response().Header().Get(name); value != "" {
+					record.Header.Set(name, value)
+				}
+			}
+			completed = config.Store.Complete(storeCtx, storeKey, record) == nil
+
+			return nil
+		}
+	}
+}
+
+// replay writes a stored response.
+func replay(c echo.Context, record *IdempotencyRecord) error {
+	for name, values := range record.Header {
+		for _, value := range values {
+			c.Response().Header().Add(name, value)
+		}
+	}
+	c.Response().Header().Set("Idempotent-Replayed", "true")
+
+	c.Response().WriteHeader(record.Status)
+	_, err := c.Response().Write(record.Body)
+	return err
+}
+
+// isMutating reports whether requests with method change server state.
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestFingerprint hashes the parts of a request a key is bound to.
+func requestFingerprint(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(req.URL.Path))
+	h.Write([]byte{0})
+	h.Write([]byte(req.URL.RawQuery))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder copies the response body while it is written.
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write implements http.ResponseWriter.
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// MemoryIdempotencyStore is an in-process IdempotencyStore. Records are lost
+// on restart and not shared between instances.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]*IdempotencyRecord
+	lastSweep time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]*IdempotencyRecord)}
+}
+
+// Begin implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Begin(_ context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if record, ok := s.records[key]; ok && now.Before(record.ExpiresAt) {
+		if !record.Completed {
+			return nil, ErrIdempotencyKeyInUse
+		}
+		copied := *record
+		return &copied, nil
+	}
+
+	s.records[key] = &IdempotencyRecord{
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(ttl),
+	}
+	return nil, nil
+}
+
+// Complete implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key string, record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = &record
+	return nil
+}
+
+// Release implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// sweep drops expired records at most once a minute. Callers hold s.mu.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
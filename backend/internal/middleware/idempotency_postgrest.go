@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+)
+
+// idempotencyTable is the table holding idempotency records:
+//
+//	key text primary key, fingerprint text, completed boolean,
+//	status integer, header jsonb, body text, expires_at timestamptz
+//
+// It holds other users' responses, so it must have row level security
+// enabled and no policies, leaving it to the service role:
+//
+//	alter table idempotency_keys enable row level security;
+const idempotencyTable = "idempotency_keys"
+
+// idempotencyRow is an IdempotencyRecord as stored in idempotencyTable.
+type idempotencyRow struct {
+	Key string `json:"key"`
+	IdempotencyRecord
+}
+
+// PostgrestIdempotencyStore is an IdempotencyStore shared by all server
+// instances through a Supabase table. It uses the client's own key, which
+// must be the service role key: the table is closed to other roles.
+type PostgrestIdempotencyStore struct {
+	client *supabase.Client
+}
+
+// NewPostgrestIdempotencyStore creates a store backed by client.
+func NewPostgrestIdempotencyStore(client *supabase.Client) *PostgrestIdempotencyStore {
+	return &PostgrestIdempotencyStore{client: client}
+}
+
+// Begin implements IdempotencyStore.
+func (s *PostgrestIdempotencyStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error) {
+	row := idempotencyRow{
+		Key: key,
+		IdempotencyRecord: IdempotencyRecord{
+			Fingerprint: fingerprint,
+			ExpiresAt:   time.Now().Add(ttl).UTC(),
+		},
+	}
+
+	// The primary key makes the insert the reservation; at most one
+	// concurrent request can win it.
+	for attempt := 0; attempt < 2; attempt++ {
+		err := s.client.InsertContext(ctx, idempotencyTable, row, "")
+		if err == nil {
+			return nil, nil
+		}
+		if !supabase.IsUniqueViolation(err) {
+			return nil, err
+		}
+
+		var existing idempotencyRow
+		err = s.client.From(idempotencyTable).
+			Eq("key", key).
+			Single().
+			ExecuteContext(ctx, &existing)
+		if supabase.IsNotFound(err) {
+			// Released in the meantime; try to reserve again.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if time.Now().Before(existing.ExpiresAt) {
+			if !existing.Completed {
+				return nil, ErrIdempotencyKeyInUse
+			}
+			return &existing.IdempotencyRecord, nil
+		}
+
+		// Expired: drop it, unless someone else replaced it already.
+		filters := []supabase.Filter{
+			{Column: "key", Operator: supabase.OpEq, Value: key},
+			{Column: "expires_at", Operator: supabase.OpLte, Value: time.Now().UTC().Format(time.RFC3339Nano)},
+		}
+		if err := s.client.DeleteContext(ctx, idempotencyTable, filters, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, ErrIdempotencyKeyInUse
+}
+
+// Complete implements IdempotencyStore.
+func (s *PostgrestIdempotencyStore) Complete(ctx context.Context, key string, record IdempotencyRecord) error {
+	filters := []supabase.Filter{
+		{Column: "key", Operator: supabase.OpEq, Value: key},
+	}
+	return s.client.UpdateContext(ctx, idempotencyTable, record, filters, "")
+}
+
+// Release implements IdempotencyStore.
+func (s *PostgrestIdempotencyStore) Release(ctx context.Context, key string) error {
+	filters := []supabase.Filter{
+		{Column: "key", Operator: supabase.OpEq, Value: key},
+	}
+	return s.client.DeleteContext(ctx, idempotencyTable, filters, "")
+}
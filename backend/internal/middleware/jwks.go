@@ -0,0 +1,270 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// jwksTTL is how long a fetched key set is used before refetching.
+	jwksTTL = 10 * time.Minute
+	// jwksMinRefresh limits refetches triggered by unknown key IDs, so
+	// tokens with made-up kids cannot hammer the JWKS endpoint.
+	jwksMinRefresh = 30 * time.Second
+	// jwksFetchTimeout bounds a single JWKS download.
+	jwksFetchTimeout = 5 * time.Second
+)
+
+// ErrUnknownKey is returned when no JWKS key matches a token.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// jwk is a JSON Web Key as found in a JWKS document.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// verificationKey is a parsed public key with the algorithm it is for.
+type verificationKey struct {
+	key interface{}
+	alg string
+}
+
+// JWKS fetches and caches the public keys used to verify asymmetrically
+// signed tokens. Keys are refetched periodically and whenever a token names
+// a key ID that is not cached, which picks up key rotations.
+type JWKS struct {
+	url        string
+	file       string
+	httpClient *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]verificationKey
+	fetchedAt time.Time
+
+	// refreshMu lets a single caller refetch while others wait for it.
+	refreshMu   sync.Mutex
+	refreshedAt time.Time
+}
+
+// NewJWKS creates a key set loaded from url, falling back to the JWKS
+// document at file when url is empty or cannot be fetched.
+func NewJWKS(url, file string) *JWKS {
+	return &JWKS{
+		url:        url,
+		file:       file,
+		httpClient: &http.Client{Timeout: jwksFetchTimeout},
+		keys:       make(map[string]verificationKey),
+	}
+}
+
+// Key returns the public key with ID kid for a token signed with alg.
+// An empty kid matches the only key of a single-key set.
+func (k *JWKS) Key(ctx context.Context, kid, alg string) (interface{}, error) {
+	k.mu.RLock()
+	key, ok := k.lookup(kid)
+	fresh := time.Since(k.fetchedAt) < jwksTTL
+	k.mu.RUnlock()
+
+	if !ok || !fresh {
+		if err := k.refresh(ctx); err != nil && !ok {
+			return nil, err
+		}
+		k.mu.RLock()
+		key, ok = k.lookup(kid)
+		k.mu.RUnlock()
+	}
+
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if key.alg != "" && key.alg != alg {
+		return nil, fmt.Errorf("key %q is for %s, token uses %s", kid, key.alg, alg)
+	}
+
+	return key.key, nil
+}
+
+// lookup finds a cached key. Callers hold k.mu.
+func (k *JWKS) lookup(kid string) (verificationKey, bool) {
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// refresh refetches the key set, unless that happened very recently.
+func (k *JWKS) refresh(ctx context.Context) error {
+	k.refreshMu.Lock()
+	defer k.refreshMu.Unlock()
+
+	if time.Since(k.refreshedAt) < jwksMinRefresh {
+		return nil
+	}
+	k.refreshedAt = time.Now()
+
+	keys, err := k.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.fetchedAt = time.Now()
+	k.mu.Unlock()
+
+	return nil
+}
+
+// load reads the JWKS document from the URL, or from the fallback file.
+func (k *JWKS) load(ctx context.Context) (map[string]verificationKey, error) {
+	var data []byte
+	var err error
+
+	if k.url != "" {
+		data, err = k.fetch(ctx)
+	}
+	if k.url == "" || (err != nil && k.file != "") {
+		if k.file == "" {
+			return nil, errors.New("no JWKS URL or file configured")
+		}
+		data, err = os.ReadFile(k.file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load JWKS: %w", err)
+	}
+
+	return parseJWKS(data)
+}
+
+// fetch downloads the JWKS document.
+func (k *JWKS) fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", k.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS fetch failed with status: %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// parseJWKS parses the signing keys of a JWKS document. Keys of unsupported
+// types are skipped.
+func parseJWKS(data []byte) (map[string]verificationKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]verificationKey)
+	for _, j := range doc.Keys {
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+
+		var key interface{}
+		var err error
+		switch j.Kty {
+		case "RSA":
+			key, err = parseRSAKey(j)
+		case "EC":
+			key, err = parseECKey(j)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse JWKS key %q: %w", j.Kid, err)
+		}
+
+		keys[j.Kid] = verificationKey{key: key, alg: j.Alg}
+	}
+
+	return keys, nil
+}
+
+func parseRSAKey(j jwk) (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(j.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(j.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func parseECKey(j jwk) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch j.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+	}
+
+	x, err := decodeBigInt(j.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(j.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	if _, err := key.ECDH(); err != nil {
+		return nil, errors.New("invalid EC point")
+	}
+
+	return key, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -2,8 +2,12 @@
 package middleware
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
@@ -11,9 +15,178 @@ import (
 
 // JWTConfig holds JWT middleware configuration.
 type JWTConfig struct {
-	// JWTSecret is the secret key used to validate Supabase JWT tokens.
-	// This should be your Supabase JWT secret from the dashboard.
+	// JWTSecret is the secret key used to validate legacy HS256 Supabase
+	// JWT tokens. Leave empty to reject HMAC-signed tokens.
 	JWTSecret string
+
+	// JWKS provides the public keys for RS256/ES256 tokens issued with
+	// Supabase asymmetric signing keys. Leave nil to reject them.
+	JWKS *JWKS
+
+	// Issuers lists the accepted "iss" values, typically the project's
+	// SUPABASE_URL + "/auth/v1". Leave empty to accept any issuer.
+	Issuers []string
+
+	// Audiences lists the accepted "aud" values; a token must name at least
+	// one of them. Leave empty to accept any audience.
+	Audiences []string
+
+	// AllowedRoles lists the accepted "role" values, e.g. "authenticated"
+	// to reject anon tokens. Leave empty to accept any role.
+	AllowedRoles []string
+
+	// Leeway is the clock skew tolerated when checking exp, nbf and iat.
+	Leeway time.Duration
+
+	// RequiredClaims lists claims that must be present in every token.
+	RequiredClaims []string
+
+	// RequireAAL2 rejects tokens whose aal claim is not aal2, i.e. sessions
+	// that have not completed an MFA challenge.
+	RequireAAL2 bool
+
+	// Revocations, if set, rejects tokens whose session, jti or user was
+	// revoked, e.g. by a logout, before they expire.
+	Revocations RevocationStore
+}
+
+// AAL2 is the assurance level of sessions that completed an MFA challenge.
+const AAL2 = "aal2"
+
+// Token error codes returned in the "error" field of 401 responses. Clients
+// should refresh the session on ErrCodeTokenExpired and sign in again on the
+// others.
+const (
+	ErrCodeTokenExpired     = "token_expired"
+	ErrCodeTokenNotYetValid = "token_not_yet_valid"
+	ErrCodeTokenMalformed   = "token_malformed"
+	ErrCodeInvalidSignature = "invalid_signature"
+	ErrCodeInvalidIssuer    = "invalid_issuer"
+	ErrCodeInvalidAudience  = "invalid_audience"
+	ErrCodeInvalidRole      = "invalid_role"
+	ErrCodeMissingClaim     = "missing_claim"
+	ErrCodeMFARequired      = "mfa_required"
+	ErrCodeTokenRevoked     = "token_revoked"
+	ErrCodeInvalidToken     = "invalid_token"
+)
+
+// tokenError is a rejected token, with the code and message sent back.
+type tokenError struct {
+	code    string
+	message string
+}
+
+// Error implements error.
+func (e *tokenError) Error() string {
+	return e.code + ": " + e.message
+}
+
+// VerifyToken checks tokenString as JWTAuth does, without the revocation
+// list and assurance level checks, and returns its claims.
+func (config JWTConfig) VerifyToken(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, tokenErr := config.validate(ctx, tokenString)
+	if tokenErr != nil {
+		return nil, tokenErr
+	}
+	return claims, nil
+}
+
+// validate parses tokenString and checks it against the configuration.
+func (config JWTConfig) validate(ctx context.Context, tokenString string) (*Claims, *tokenError) {
+	raw := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, raw, config.keyFunc(ctx),
+		jwt.WithLeeway(config.Leeway),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, parseError(err)
+	}
+
+	for _, name := range config.RequiredClaims {
+		if value, ok := raw[name]; !ok || value == nil || value == "" {
+			return nil, &tokenError{ErrCodeMissingClaim, "Token is missing the " + name + " claim"}
+		}
+	}
+
+	// Decode the verified claims into the typed form.
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, &tokenError{ErrCodeTokenMalformed, "Token claims are malformed"}
+	}
+	var claims Claims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return nil, &tokenError{ErrCodeTokenMalformed, "Token claims are malformed"}
+	}
+
+	if len(config.Issuers) > 0 && !contains(config.Issuers, claims.Issuer) {
+		return nil, &tokenError{ErrCodeInvalidIssuer, "Token was not issued by this project"}
+	}
+	if len(config.Audiences) > 0 && !containsAny(config.Audiences, claims.Audience) {
+		return nil, &tokenError{ErrCodeInvalidAudience, "Token is not intended for this API"}
+	}
+	if len(config.AllowedRoles) > 0 && !contains(config.AllowedRoles, claims.Role) {
+		return nil, &tokenError{ErrCodeInvalidRole, "Token role is not allowed"}
+	}
+	if config.RequireAAL2 && claims.AAL != AAL2 {
+		return nil, &tokenError{ErrCodeMFARequired, "Multi-factor authentication is required"}
+	}
+
+	return &claims, nil
+}
+
+// parseError maps a jwt parse error to a token error.
+func parseError(err error) *tokenError {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return &tokenError{ErrCodeTokenExpired, "Token has expired"}
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return &tokenError{ErrCodeTokenNotYetValid, "Token is not valid yet"}
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return &tokenError{ErrCodeTokenMalformed, "Token is malformed"}
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return &tokenError{ErrCodeInvalidSignature, "Token signature is invalid"}
+	default:
+		return &tokenError{ErrCodeInvalidToken, "Invalid token"}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(values []string, candidates []string) bool {
+	for _, candidate := range candidates {
+		if contains(values, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyFunc returns the key that verifies token, based on its algorithm.
+func (config JWTConfig) keyFunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if config.JWTSecret == "" {
+				return nil, jwt.ErrSignatureInvalid
+			}
+			return []byte(config.JWTSecret), nil
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA:
+			if config.JWKS == nil {
+				return nil, jwt.ErrSignatureInvalid
+			}
+			kid, _ := token.Header["kid"].(string)
+			return config.JWKS.Key(ctx, kid, token.Method.Alg())
+		default:
+			return nil, jwt.ErrSignatureInvalid
+		}
+	}
 }
 
 // Claims represents the JWT claims from Supabase.
@@ -21,11 +194,20 @@ type Claims struct {
 	Sub   string `json:"sub"` // User ID
 	Email string `json:"email"`
 	Role  string `json:"role"`
+	// Scope is the space-separated OAuth scope list, if any.
+	Scope string `json:"scope,omitempty"`
+	// AAL is the authenticator assurance level: aal1, or aal2 once an MFA
+	// factor was verified in the session.
+	AAL string `json:"aal,omitempty"`
+	// SessionID identifies the GoTrue session the token belongs to.
+	SessionID   string      `json:"session_id,omitempty"`
+	AppMetadata AppMetadata `json:"app_metadata"`
 	jwt.RegisteredClaims
 }
 
 // JWTAuth creates a JWT authentication middleware.
-// It validates the Authorization header contains a valid Supabase JWT token.
+// It validates the Authorization header contains a valid Supabase JWT token
+// whose issuer, audience and role are accepted by config.
 func JWTAuth(config JWTConfig) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -49,28 +231,36 @@ func JWTAuth(config JWTConfig) echo.MiddlewareFunc {
 			tokenString := parts[1]
 
 			// Parse and validate the token
-			token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-				// Validate signing method
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, jwt.ErrSignatureInvalid
-				}
-				return []byte(config.JWTSecret), nil
-			})
-
-			if err != nil || !token.Valid {
+			claims, tokenErr := config.validate(c.Request().Context(), tokenString)
+			if tokenErr != nil {
 				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error":   "unauthorized",
-					"message": "Invalid or expired token",
+					"error":   tokenErr.code,
+					"message": tokenErr.message,
 				})
 			}
 
-			// Extract claims and set in context
-			if claims, ok := token.Claims.(*Claims); ok {
-				c.Set("user_id", claims.Sub)
-				c.Set("user_email", claims.Email)
-				c.Set("user_role", claims.Role)
+			if config.Revocations != nil {
+				revoked, err := isRevoked(c.Request().Context(), config.Revocations, claims)
+				if err != nil {
+					return c.JSON(http.StatusServiceUnavailable, map[string]string{
+						"error":   "service_unavailable",
+						"message": "Token revocation list unavailable",
+					})
+				}
+				if revoked {
+					return c.JSON(http.StatusUnauthorized, map[string]string{
+						"error":   ErrCodeTokenRevoked,
+						"message": "Token has been revoked",
+					})
+				}
 			}
 
+			// Set claims in context
+			c.Set("user_id", claims.Sub)
+			c.Set("user_email", claims.Email)
+			c.Set("user_role", claims.Role)
+			c.Set("claims", claims)
+
 			return next(c)
 		}
 	}
@@ -93,3 +283,30 @@ func GetUserEmail(c echo.Context) string {
 	}
 	return ""
 }
+
+// GetUserRole extracts the database role claim from the request context.
+// Returns empty string if not found.
+func GetUserRole(c echo.Context) string {
+	if role, ok := c.Get("user_role").(string); ok {
+		return role
+	}
+	return ""
+}
+
+// GetSessionID extracts the token's session ID from the request context.
+// Returns empty string if not found.
+func GetSessionID(c echo.Context) string {
+	if claims := GetClaims(c); claims != nil {
+		return claims.SessionID
+	}
+	return ""
+}
+
+// GetClaims extracts the validated token claims from the request context.
+// Returns nil if the request was not authenticated.
+func GetClaims(c echo.Context) *Claims {
+	if claims, ok := c.Get("claims").(*Claims); ok {
+		return claims
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRevocationStoreFull is returned by Revoke when a store cannot hold
+// another revocation.
+var ErrRevocationStoreFull = errors.New("revocation store is full")
+
+// SessionRevocationKey returns the revocation key of a GoTrue session.
+func SessionRevocationKey(sessionID string) string { return "session:" + sessionID }
+
+// TokenRevocationKey returns the revocation key of a token's jti claim.
+func TokenRevocationKey(jti string) string { return "jti:" + jti }
+
+// UserRevocationKey returns the revocation key of all of a user's tokens.
+func UserRevocationKey(userID string) string { return "user:" + userID }
+
+// RevocationStore records revoked sessions, tokens and users until the
+// tokens they affect have expired. A revoked session or token ID rejects
+// every token that carries it; a revoked user rejects the user's tokens
+// issued before the revocation.
+type RevocationStore interface {
+	// Revoke revokes key as of now. The record may be dropped after
+	// expiresAt, when every token it affects has expired.
+	Revoke(ctx context.Context, key string, expiresAt time.Time) error
+	// RevokedAt returns when key was revoked, if it is.
+	RevokedAt(ctx context.Context, key string) (time.Time, bool, error)
+}
+
+// isRevoked checks claims against store.
+func isRevoked(ctx context.Context, store RevocationStore, claims *Claims) (bool, error) {
+	var keys []string
+	if claims.SessionID != "" {
+		keys = append(keys, SessionRevocationKey(claims.SessionID))
+	}
+	if claims.ID != "" {
+		keys = append(keys, TokenRevocationKey(claims.ID))
+	}
+	for _, key := range keys {
+		if _, revoked, err := store.RevokedAt(ctx, key); err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	revokedAt, revoked, err := store.RevokedAt(ctx, UserRevocationKey(claims.Sub))
+	if err != nil || !revoked {
+		return false, err
+	}
+	// Tokens without iat cannot be told apart from older ones. iat has
+	// whole seconds, so tokens issued in the second of the revocation are
+	// accepted: rejecting the new tokens of that second would be worse
+	// than accepting the old ones.
+	return claims.IssuedAt == nil || claims.IssuedAt.Time.Before(revokedAt.Truncate(time.Second)), nil
+}
+
+// revocation is a stored revocation.
+type revocation struct {
+	key       string
+	revokedAt time.Time
+	expiresAt time.Time
+	// index is the position of the revocation in the expiry heap.
+	index int
+}
+
+// revocationHeap orders revocations by expiry, soonest first.
+type revocationHeap []*revocation
+
+func (h revocationHeap) Len() int           { return len(h) }
+func (h revocationHeap) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h revocationHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *revocationHeap) Push(x interface{}) {
+	r := x.(*revocation)
+	r.index = len(*h)
+	*h = append(*h, r)
+}
+func (h *revocationHeap) Pop() interface{} {
+	old := *h
+	r := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return r
+}
+
+// MemoryRevocationStore is an in-process RevocationStore holding at most a
+// fixed number of revocations. Revocations are dropped once expired; while
+// the store is full, new ones fail with ErrRevocationStoreFull rather than
+// evicting live ones. Revocations are lost on restart and not shared
+// between instances.
+type MemoryRevocationStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*revocation
+	byExpiry   revocationHeap
+}
+
+// NewMemoryRevocationStore creates an empty store holding at most
+// maxEntries revocations.
+func NewMemoryRevocationStore(maxEntries int) *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*revocation),
+	}
+}
+
+// Revoke implements RevocationStore.
+func (s *MemoryRevocationStore) Revoke(_ context.Context, key string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.expire(now)
+
+	if r, ok := s.entries[key]; ok {
+		// Keep the longest expiry.
+		r.revokedAt = now
+		if expiresAt.After(r.expiresAt) {
+			r.expiresAt = expiresAt
+			heap.Fix(&s.byExpiry, r.index)
+		}
+		return nil
+	}
+
+	if len(s.entries) >= s.maxEntries {
+		return ErrRevocationStoreFull
+	}
+
+	r := &revocation{key: key, revokedAt: now, expiresAt: expiresAt}
+	s.entries[key] = r
+	heap.Push(&s.byExpiry, r)
+	return nil
+}
+
+// RevokedAt implements RevocationStore.
+func (s *MemoryRevocationStore) RevokedAt(_ context.Context, key string) (time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.entries[key]
+	if !ok || !time.Now().Before(r.expiresAt) {
+		return time.Time{}, false, nil
+	}
+	return r.revokedAt, true, nil
+}
+
+// expire drops expired revocations. Callers hold s.mu.
+func (s *MemoryRevocationStore) expire(now time.Time) {
+	for s.byExpiry.Len() > 0 && !now.Before(s.byExpiry[0].expiresAt) {
+		r := heap.Pop(&s.byExpiry).(*revocation)
+		delete(s.entries, r.key)
+	}
+}
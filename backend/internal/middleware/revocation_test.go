@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestMemoryRevocationStoreRevokeAgain(t *testing.T) {
+	store := NewMemoryRevocationStore(2)
+	ctx := context.Background()
+	now := time.Now()
+
+	// Revoking a key again keeps the longest expiry, and takes one entry.
+	_ = store.Revoke(ctx, "session:a", now.Add(50*time.Millisecond))
+	_ = store.Revoke(ctx, "session:a", now.Add(time.Hour))
+	_ = store.Revoke(ctx, "session:a", now.Add(10*time.Millisecond))
+	if err := store.Revoke(ctx, "session:b", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke(session:b): %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, revoked, _ := store.RevokedAt(ctx, "session:a"); !revoked {
+		t.Error("session:a expired with its shorter expiry")
+	}
+}
+
+func TestMemoryRevocationStoreFull(t *testing.T) {
+	store := NewMemoryRevocationStore(2)
+	ctx := context.Background()
+	now := time.Now()
+
+	_ = store.Revoke(ctx, "session:a", now.Add(time.Hour))
+	_ = store.Revoke(ctx, "session:b", now.Add(50*time.Millisecond))
+
+	// Full: live revocations are kept and the new one fails.
+	if err := store.Revoke(ctx, "session:c", now.Add(2*time.Hour)); err != ErrRevocationStoreFull {
+		t.Fatalf("Revoke(session:c) = %v, want ErrRevocationStoreFull", err)
+	}
+	for key, want := range map[string]bool{"session:a": true, "session:b": true, "session:c": false} {
+		if _, revoked, _ := store.RevokedAt(ctx, key); revoked != want {
+			t.Errorf("%s revoked = %v, want %v", key, revoked, want)
+		}
+	}
+
+	// Expired revocations make room.
+	time.Sleep(100 * time.Millisecond)
+	if err := store.Revoke(ctx, "session:c", now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("Revoke(session:c) after session:b expired: %v", err)
+	}
+	if _, revoked, _ := store.RevokedAt(ctx, "session:c"); !revoked {
+		t.Error("session:c is not revoked")
+	}
+}
+
+func TestIsRevokedUser(t *testing.T) {
+	store := NewMemoryRevocationStore(10)
+	ctx := context.Background()
+
+	if err := store.Revoke(ctx, UserRevocationKey("user-1"), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// Issued after the revocation, possibly in the same second.
+	issued := time.Now()
+
+	tests := []struct {
+		name     string
+		issuedAt *jwt.NumericDate
+		want     bool
+	}{
+		{name: "issued before", issuedAt: jwt.NewNumericDate(issued.Add(-2 * time.Second)), want: true},
+		{name: "issued after, in the same second", issuedAt: jwt.NewNumericDate(issued), want: false},
+		{name: "issued later", issuedAt: jwt.NewNumericDate(issued.Add(time.Second)), want: false},
+		{name: "without iat", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &Claims{Sub: "user-1"}
+			claims.IssuedAt = tt.issuedAt
+
+			revoked, err := isRevoked(ctx, store, claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if revoked != tt.want {
+				t.Errorf("revoked = %v, want %v", revoked, tt.want)
+			}
+		})
+	}
+}
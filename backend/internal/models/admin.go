@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// AdminUserResponse represents a user as seen by admins and support staff.
+type AdminUserResponse struct {
+	ID               string                 `json:"id"`
+	Email            string                 `json:"email"`
+	Phone            string                 `json:"phone,omitempty"`
+	AppMetadata      map[string]interface{} `json:"app_metadata,omitempty"`
+	UserMetadata     map[string]interface{} `json:"user_metadata,omitempty"`
+	EmailConfirmedAt *time.Time             `json:"email_confirmed_at,omitempty"`
+	LastSignInAt     *time.Time             `json:"last_sign_in_at,omitempty"`
+	BannedUntil      *time.Time             `json:"banned_until,omitempty"`
+	Disabled         bool                   `json:"disabled"`
+	CreatedAt        time.Time              `json:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at"`
+}
+
+// AdminListUsersRequest represents the query of a user listing. Zero
+// values select the first page and the default page size.
+type AdminListUsersRequest struct {
+	Page    int `query:"page" json:"page" validate:"omitempty,min=1"`
+	PerPage int `query:"per_page" json:"per_page" validate:"omitempty,min=1,max=100"`
+}
+
+// AdminUserListResponse represents a page of users.
+type AdminUserListResponse struct {
+	Users   []AdminUserResponse `json:"users"`
+	Page    int                 `json:"page"`
+	PerPage int                 `json:"per_page"`
+	Total   int                 `json:"total"`
+}
+
+// AdminUpdateUserRequest represents an admin change to a user. Only the
+// fields that are set are changed.
+type AdminUpdateUserRequest struct {
+	// Email, like any other field, is required when no other one is set.
+	Email        *string `json:"email,omitempty" validate:"required_without_all=EmailConfirm Disabled AppMetadata UserMetadata,omitnil,email"`
+	EmailConfirm *bool   `json:"email_confirm,omitempty"`
+	// Disabled bans the user from signing in, or lifts the ban.
+	Disabled     *bool                  `json:"disabled,omitempty"`
+	AppMetadata  map[string]interface{} `json:"app_metadata,omitempty"`
+	UserMetadata map[string]interface{} `json:"user_metadata,omitempty"`
+}
+
+// InviteUserRequest represents an invite sent by an admin.
+type InviteUserRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	// RedirectTo is where the invite link leads after it is accepted.
+	RedirectTo string                 `json:"redirect_to,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// BatchOp is the operation a batch request applies to every item.
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchUpdate BatchOp = "update"
+	BatchDelete BatchOp = "delete"
+)
+
+// BatchRequest represents a bulk create, update or delete.
+type BatchRequest struct {
+	Op BatchOp `json:"op" validate:"required,oneof=create update delete"`
+	// Items holds the new items for create, at most 100.
+	Items []CreateItemRequest `json:"items,omitempty" validate:"required_if=Op create,omitempty,min=1,max=100,dive"`
+	// IDs holds the target items for update and delete, at most 100.
+	IDs []string `json:"ids,omitempty" validate:"required_unless=Op create,omitempty,min=1,max=100,dive,uuid"`
+	// Changes is applied to every item for update.
+	Changes *UpdateItemRequest `json:"changes,omitempty" validate:"required_if=Op update,omitnil"`
+	// Permanent deletes items for good instead of moving them to the trash.
+	Permanent bool `json:"permanent,omitempty"`
+}
+
+// BatchResult reports the outcome for one item of a batch, in request order.
+type BatchResult struct {
+	Index  int           `json:"index"`
+	ID     string        `json:"id,omitempty"`
+	Status int           `json:"status"`
+	Item   *ItemResponse `json:"item,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// BatchResponse represents the per-item results of a batch.
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
@@ -13,17 +13,18 @@ type Item struct {
 	Completed   bool       `json:"completed"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
 }
 
 // CreateItemRequest represents the request to create an item.
 type CreateItemRequest struct {
-	Title       string  `json:"title" validate:"required"`
+	Title       string  `json:"title" validate:"required,notblank"`
 	Description *string `json:"description,omitempty"`
 }
 
 // UpdateItemRequest represents the request to update an item.
 type UpdateItemRequest struct {
-	Title       *string `json:"title,omitempty"`
+	Title       *string `json:"title,omitempty" validate:"omitnil,min=1,notblank"`
 	Description *string `json:"description,omitempty"`
 	Completed   *bool   `json:"completed,omitempty"`
 }
@@ -36,6 +37,21 @@ type ItemResponse struct {
 	Completed   bool       `json:"completed"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Version returns the timestamp of the item's latest write, used to detect
+// concurrent modifications.
+func (i *Item) Version() time.Time {
+	if i.UpdatedAt != nil {
+		return *i.UpdatedAt
+	}
+	return i.CreatedAt
+}
+
+// IsTrashed reports whether the item is in the trash.
+func (i *Item) IsTrashed() bool {
+	return i.DeletedAt != nil
 }
 
 // ToResponse converts an Item to ItemResponse.
@@ -47,5 +63,13 @@ func (i *Item) ToResponse() ItemResponse {
 		Completed:   i.Completed,
 		CreatedAt:   i.CreatedAt,
 		UpdatedAt:   i.UpdatedAt,
+		DeletedAt:   i.DeletedAt,
 	}
 }
+
+// ItemListResponse represents a page of items in API responses.
+type ItemListResponse struct {
+	Items      []ItemResponse `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	HasMore    bool           `json:"has_more"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// FactorResponse represents an enrolled MFA factor.
+type FactorResponse struct {
+	ID           string    `json:"id"`
+	FriendlyName string    `json:"friendly_name,omitempty"`
+	FactorType   string    `json:"factor_type"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// FactorListResponse represents the user's enrolled factors.
+type FactorListResponse struct {
+	Factors []FactorResponse `json:"factors"`
+}
+
+// EnrollFactorRequest represents a TOTP enrollment.
+type EnrollFactorRequest struct {
+	FriendlyName string `json:"friendly_name,omitempty" validate:"max=100"`
+}
+
+// EnrollFactorResponse holds a new, unverified TOTP factor and what the
+// user needs to add it to an authenticator app: the otpauth:// URI, the
+// same URI as an SVG QR code, and the secret for manual entry.
+type EnrollFactorResponse struct {
+	ID           string `json:"id"`
+	FriendlyName string `json:"friendly_name,omitempty"`
+	URI          string `json:"uri"`
+	QRCode       string `json:"qr_code"`
+	Secret       string `json:"secret"`
+}
+
+// ChallengeResponse represents an MFA challenge.
+type ChallengeResponse struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// VerifyFactorRequest represents a code entered for a factor. Without a
+// challenge ID, a challenge is created and verified in one step.
+type VerifyFactorRequest struct {
+	ChallengeID string `json:"challenge_id,omitempty" validate:"omitempty,uuid"`
+	Code        string `json:"code" validate:"required,len=6,numeric"`
+}
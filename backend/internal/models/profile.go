@@ -0,0 +1,25 @@
+package models
+
+// UpdateProfileRequest represents a change to the current user's profile.
+// Only the fields that are set are changed; an empty string clears one.
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name,omitempty" validate:"omitnil,max=100"`
+	// AvatarURL is an http or https URL.
+	AvatarURL *string `json:"avatar_url,omitempty" validate:"omitnil,eq=|http_url"`
+	// Locale is a BCP 47 language tag such as en or pt-BR.
+	Locale *string `json:"locale,omitempty" validate:"omitnil,eq=|bcp47_language_tag"`
+	// Timezone is an IANA name such as Europe/Madrid.
+	Timezone *string `json:"timezone,omitempty" validate:"omitnil,eq=|timezone"`
+}
+
+// ChangeEmailRequest represents a change of the current user's email.
+type ChangeEmailRequest struct {
+	Email           string `json:"email" validate:"required,email"`
+	CurrentPassword string `json:"current_password" validate:"required"`
+}
+
+// ChangePasswordRequest represents a change of the current user's password.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
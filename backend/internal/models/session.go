@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// SessionResponse represents a device the user is signed in on.
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	DeviceName string    `json:"device_name,omitempty"`
+	Platform   string    `json:"platform,omitempty"`
+	AppVersion string    `json:"app_version,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// Current is set for the session making the request.
+	Current bool `json:"current"`
+}
+
+// SessionListResponse represents the user's active sessions.
+type SessionListResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import "time"
+
+// Tombstone records that an item was deleted, so offline clients can drop
+// it from their local store. Stored in the item_tombstones table.
+type Tombstone struct {
+	ItemID    string    `json:"item_id"`
+	UserID    string    `json:"user_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// TombstoneResponse represents a deleted item in sync responses.
+type TombstoneResponse struct {
+	ID        string    `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// ToResponse converts a Tombstone to TombstoneResponse.
+func (t *Tombstone) ToResponse() TombstoneResponse {
+	return TombstoneResponse{
+		ID:        t.ItemID,
+		DeletedAt: t.DeletedAt,
+	}
+}
+
+// SyncResponse represents the changes since a sync token. HasMore is set
+// when more changes follow; SyncToken then continues this sync.
+type SyncResponse struct {
+	Items     []ItemResponse      `json:"items"`
+	Deleted   []TombstoneResponse `json:"deleted"`
+	SyncToken string              `json:"sync_token"`
+	HasMore   bool                `json:"has_more"`
+}
+
+// ConflictPolicy decides how a pushed mutation is applied when the item
+// changed on the server since the client's base version.
+type ConflictPolicy string
+
+const (
+	// ConflictServerWins keeps the server item and rejects the mutation.
+	ConflictServerWins ConflictPolicy = "server_wins"
+	// ConflictClientWins applies the mutation over the server item.
+	ConflictClientWins ConflictPolicy = "client_wins"
+	// ConflictMerge applies the fields the server has not changed since the
+	// base version and keeps the server value for the others.
+	ConflictMerge ConflictPolicy = "merge"
+)
+
+// Valid reports whether p is a known policy.
+func (p ConflictPolicy) Valid() bool {
+	switch p {
+	case ConflictServerWins, ConflictClientWins, ConflictMerge:
+		return true
+	}
+	return false
+}
+
+// MutationOp is the kind of change a pushed mutation makes.
+type MutationOp string
+
+const (
+	MutationCreate MutationOp = "create"
+	MutationUpdate MutationOp = "update"
+	MutationDelete MutationOp = "delete"
+)
+
+// Mutation is a change made offline by a client.
+type Mutation struct {
+	// ClientID identifies the mutation. For creates it also stands for the
+	// new item, so later mutations in the same push may use it as ID.
+	ClientID string     `json:"client_id" validate:"required"`
+	Op       MutationOp `json:"op" validate:"required,oneof=create update delete"`
+	// ID is the target item of updates and deletes: a server item ID, or
+	// the client ID of a create earlier in the push. Either is a UUID.
+	ID string `json:"id,omitempty" validate:"required_unless=Op create,omitempty,uuid"`
+	// BaseVersion is the item version (updated_at, or created_at if never
+	// updated) the client based its change on.
+	BaseVersion *time.Time `json:"base_version,omitempty"`
+	// Create holds the new item for create mutations.
+	Create *CreateItemRequest `json:"create,omitempty" validate:"required_if=Op create,omitnil"`
+	// Changes holds the changed fields for update mutations, and Base the
+	// client's values of those fields at BaseVersion, used for merging.
+	Changes *UpdateItemRequest `json:"changes,omitempty" validate:"required_if=Op update,omitnil"`
+	Base    *UpdateItemRequest `json:"base,omitempty" validate:"-"`
+}
+
+// PushRequest represents an ordered batch of offline mutations.
+type PushRequest struct {
+	// Mutations holds at most 100 mutations.
+	Mutations []Mutation     `json:"mutations" validate:"required,min=1,max=100,dive"`
+	Policy    ConflictPolicy `json:"policy,omitempty" validate:"omitempty,oneof=server_wins client_wins merge"`
+}
+
+// MutationStatus is the outcome of a pushed mutation.
+type MutationStatus string
+
+const (
+	MutationApplied  MutationStatus = "applied"
+	MutationMerged   MutationStatus = "merged"
+	MutationConflict MutationStatus = "conflict"
+	MutationNotFound MutationStatus = "not_found"
+	MutationFailed   MutationStatus = "failed"
+)
+
+// MutationResult reports what happened to a pushed mutation.
+type MutationResult struct {
+	ClientID          string         `json:"client_id"`
+	Status            MutationStatus `json:"status"`
+	Item              *ItemResponse  `json:"item,omitempty"`
+	ConflictingFields []string       `json:"conflicting_fields,omitempty"`
+	Error             string         `json:"error,omitempty"`
+}
+
+// PushResponse represents the per-mutation results of a push.
+type PushResponse struct {
+	Results []MutationResult `json:"results"`
+}
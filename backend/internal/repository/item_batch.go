@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/{{.ProjectName}}/backend/internal/models"
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+)
+
+// CreateMany inserts several items for a user in a single request. The
+// created items are returned in request order.
+func (r *ItemRepository) CreateMany(ctx context.Context, userID string, reqs []models.CreateItemRequest, userToken string) ([]models.Item, error) {
+	now := time.Now().UTC()
+	rows := make([]map[string]interface{}, len(reqs))
+	for i, req := range reqs {
+		rows[i] = newItemRow(userID, req, now)
+	}
+
+	var result []models.Item
+	if err := r.client.InsertReturningContext(ctx, "items", rows, &result, userToken); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetByIDs retrieves the items with the given IDs in a single query. IDs
+// that do not exist or are not visible to the token are left out.
+func (r *ItemRepository) GetByIDs(ctx context.Context, ids []string, userToken string) ([]models.Item, error) {
+	var items []models.Item
+	err := r.client.From("items").
+		Filter("id", supabase.OpIn, supabase.InList(ids...)).
+		WithToken(userToken).
+		ExecuteContext(ctx, &items)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// UpdateMany applies the same changes to several of a user's items and
+// returns the updated items.
+func (r *ItemRepository) UpdateMany(ctx context.Context, userID string, ids []string, req models.UpdateItemRequest, userToken string) ([]models.Item, error) {
+	var result []models.Item
+	err := r.client.UpdateReturningContext(ctx, "items", itemUpdates(req), ownedFilters(userID, ids), &result, userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// TrashMany moves several of a user's items to the trash and returns them.
+func (r *ItemRepository) TrashMany(ctx context.Context, userID string, ids []string, userToken string) ([]models.Item, error) {
+	now := time.Now().UTC()
+	updates := map[string]interface{}{
+		"deleted_at": now,
+		"updated_at": now,
+	}
+
+	var result []models.Item
+	err := r.client.UpdateReturningContext(ctx, "items", updates, ownedFilters(userID, ids), &result, userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteMany permanently removes several of a user's items, leaving
+// tombstones like Delete, and returns the removed items.
+func (r *ItemRepository) DeleteMany(ctx context.Context, userID string, ids []string, userToken string) ([]models.Item, error) {
+	now := time.Now().UTC()
+	tombstones := make([]models.Tombstone, len(ids))
+	for i, id := range ids {
+		tombstones[i] = models.Tombstone{ItemID: id, UserID: userID, DeletedAt: now}
+	}
+
+	if err := r.client.InsertContext(ctx, "item_tombstones", tombstones, userToken); err != nil {
+		return nil, err
+	}
+
+	var deleted []models.Item
+	err := r.client.DeleteReturningContext(ctx, "items", ownedFilters(userID, ids), &deleted, userToken)
+	if err != nil {
+		tombstoneFilters := []supabase.Filter{
+			{Column: "item_id", Operator: supabase.OpIn, Value: supabase.InList(ids...)},
+		}
+		_ = r.client.DeleteContext(context.WithoutCancel(ctx), "item_tombstones", tombstoneFilters, userToken)
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// ownedFilters matches the given items of a user.
+func ownedFilters(userID string, ids []string) []supabase.Filter {
+	return []supabase.Filter{
+		{Column: "id", Operator: supabase.OpIn, Value: supabase.InList(ids...)},
+		{Column: "user_id", Operator: supabase.OpEq, Value: userID},
+	}
+}
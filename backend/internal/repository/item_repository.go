@@ -2,12 +2,20 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/{{.ProjectName}}/backend/internal/models"
 	"github.com/{{.ProjectName}}/backend/internal/supabase"
 )
 
+// ErrVersionMismatch is returned when a conditional write finds the item
+// changed since it was read.
+var ErrVersionMismatch = errors.New("item version mismatch")
+
 // ItemRepository handles item data operations.
 type ItemRepository struct {
 	client *supabase.Client
@@ -19,17 +27,11 @@ func NewItemRepository(client *supabase.Client) *ItemRepository {
 }
 
 // Create inserts a new item for a user.
-func (r *ItemRepository) Create(userID string, req models.CreateItemRequest, userToken string) (*models.Item, error) {
-	item := map[string]interface{}{
-		"user_id":     userID,
-		"title":       req.Title,
-		"description": req.Description,
-		"completed":   false,
-		"created_at":  time.Now().UTC(),
-	}
+func (r *ItemRepository) Create(ctx context.Context, userID string, req models.CreateItemRequest, userToken string) (*models.Item, error) {
+	item := newItemRow(userID, req, time.Now().UTC())
 
 	var result []models.Item
-	err := r.client.InsertReturning("items", item, &result, userToken)
+	err := r.client.InsertReturningContext(ctx, "items", item, &result, userToken)
 	if err != nil {
 		return nil, err
 	}
@@ -41,14 +43,25 @@ func (r *ItemRepository) Create(userID string, req models.CreateItemRequest, use
 	return &result[0], nil
 }
 
+// newItemRow builds the inserted row for a new item.
+func newItemRow(userID string, req models.CreateItemRequest, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":     userID,
+		"title":       req.Title,
+		"description": req.Description,
+		"completed":   false,
+		"created_at":  now,
+	}
+}
+
 // GetByID retrieves a single item by ID.
-func (r *ItemRepository) GetByID(id string, userToken string) (*models.Item, error) {
+func (r *ItemRepository) GetByID(ctx context.Context, id string, userToken string) (*models.Item, error) {
 	var item models.Item
 	err := r.client.From("items").
 		Eq("id", id).
 		WithToken(userToken).
 		Single().
-		Execute(&item)
+		ExecuteContext(ctx, &item)
 
 	if err != nil {
 		return nil, err
@@ -57,14 +70,15 @@ func (r *ItemRepository) GetByID(id string, userToken string) (*models.Item, err
 	return &item, nil
 }
 
-// GetByUserID retrieves all items for a user.
-func (r *ItemRepository) GetByUserID(userID string, userToken string) ([]models.Item, error) {
+// GetByUserID retrieves all items for a user, excluding trashed ones.
+func (r *ItemRepository) GetByUserID(ctx context.Context, userID string, userToken string) ([]models.Item, error) {
 	var items []models.Item
 	err := r.client.From("items").
 		Eq("user_id", userID).
+		Filter("deleted_at", supabase.OpIs, "null").
 		Order("created_at", false).
 		WithToken(userToken).
-		Execute(&items)
+		ExecuteContext(ctx, &items)
 
 	if err != nil {
 		return nil, err
@@ -73,8 +87,200 @@ func (r *ItemRepository) GetByUserID(userID string, userToken string) ([]models.
 	return items, nil
 }
 
-// Update modifies an existing item.
-func (r *ItemRepository) Update(id string, req models.UpdateItemRequest, userToken string) (*models.Item, error) {
+// itemSortColumns lists the item columns a list can be ordered by,
+// mapped to whether the column is nullable.
+var itemSortColumns = map[string]bool{
+	"created_at": false,
+	"updated_at": true,
+	"title":      false,
+	"completed":  false,
+}
+
+// defaultItemSort orders item lists newest first.
+var defaultItemSort = []SortField{
+	{Column: "created_at", Descending: true},
+}
+
+// IsItemSortColumn reports whether items can be ordered by column.
+func IsItemSortColumn(column string) bool {
+	_, ok := itemSortColumns[column]
+	return ok
+}
+
+// ItemFilter narrows an item listing. Zero values are ignored.
+type ItemFilter struct {
+	Completed     *bool
+	Search        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+}
+
+// ListOptions controls a paginated item listing.
+type ListOptions struct {
+	Limit  int
+	Cursor *Cursor
+	Filter ItemFilter
+	// Sort defaults to newest first; the item ID is always appended as a
+	// final tie-breaker so that pages are stable.
+	Sort []SortField
+}
+
+// ItemPage is one page of a paginated item listing.
+type ItemPage struct {
+	Items []models.Item
+	// Next is the cursor for the following page, nil on the last page.
+	Next *Cursor
+}
+
+// ListByUserID retrieves one page of a user's items, excluding trashed ones.
+func (r *ItemRepository) ListByUserID(ctx context.Context, userID string, opts ListOptions, userToken string) (*ItemPage, error) {
+	sort := itemSort(opts.Sort)
+
+	q := r.client.From("items").
+		Eq("user_id", userID).
+		Filter("deleted_at", supabase.OpIs, "null").
+		WithToken(userToken)
+
+	applyItemFilter(q, opts.Filter)
+
+	if opts.Cursor != nil {
+		if err := applyKeyset(q, sort, opts.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	applyOrder(q, sort)
+
+	// Fetch one extra row to learn whether another page follows.
+	var items []models.Item
+	if err := q.Limit(opts.Limit+1).ExecuteContext(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	page := &ItemPage{Items: items}
+	if len(items) > opts.Limit {
+		page.Items = items[:opts.Limit]
+		last := page.Items[opts.Limit-1]
+
+		cursor := &Cursor{Sort: sortKey(sort)}
+		for _, f := range sort {
+			cursor.Values = append(cursor.Values, itemColumnValue(last, f.Column))
+		}
+		page.Next = cursor
+	}
+
+	return page, nil
+}
+
+// itemSort completes a requested ordering with nullability and the ID
+// tie-breaker.
+func itemSort(requested []SortField) []SortField {
+	if len(requested) == 0 {
+		requested = defaultItemSort
+	}
+
+	sort := make([]SortField, 0, len(requested)+1)
+	for _, f := range requested {
+		f.Nullable = itemSortColumns[f.Column]
+		sort = append(sort, f)
+	}
+
+	last := sort[len(sort)-1]
+	return append(sort, SortField{Column: "id", Descending: last.Descending})
+}
+
+// applyItemFilter adds the filter conditions to the query.
+func applyItemFilter(q *supabase.QueryBuilder, f ItemFilter) {
+	if f.Completed != nil {
+		q.Filter("completed", supabase.OpEq, strconv.FormatBool(*f.Completed))
+	}
+	if f.Search != "" {
+		q.Filter("title", supabase.OpILike, "*"+escapeLike(f.Search)+"*")
+	}
+	if f.CreatedAfter != nil {
+		q.Filter("created_at", supabase.OpGte, formatTime(*f.CreatedAfter))
+	}
+	if f.CreatedBefore != nil {
+		q.Filter("created_at", supabase.OpLt, formatTime(*f.CreatedBefore))
+	}
+	if f.UpdatedAfter != nil {
+		q.Filter("updated_at", supabase.OpGte, formatTime(*f.UpdatedAfter))
+	}
+	if f.UpdatedBefore != nil {
+		q.Filter("updated_at", supabase.OpLt, formatTime(*f.UpdatedBefore))
+	}
+}
+
+// escapeLike neutralizes LIKE wildcards in user input. PostgREST turns
+// '*' into '%' itself, so it cannot be escaped and is dropped instead.
+func escapeLike(s string) string {
+	s = strings.ReplaceAll(s, "*", "")
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "%", `\%`)
+	return strings.ReplaceAll(s, "_", `\_`)
+}
+
+// itemColumnValue returns the value of a sortable column as a cursor value.
+func itemColumnValue(item models.Item, column string) *string {
+	var v string
+	switch column {
+	case "id":
+		v = item.ID
+	case "title":
+		v = item.Title
+	case "completed":
+		v = strconv.FormatBool(item.Completed)
+	case "created_at":
+		v = formatTime(item.CreatedAt)
+	case "updated_at":
+		if item.UpdatedAt == nil {
+			return nil
+		}
+		v = formatTime(*item.UpdatedAt)
+	default:
+		return nil
+	}
+	return &v
+}
+
+// formatTime renders t the way it is compared against in PostgREST filters.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// Update modifies an existing item. If ifUnchanged is set, the update only
+// applies while the item is still at the version ifUnchanged was read at,
+// and ErrVersionMismatch is returned otherwise.
+func (r *ItemRepository) Update(ctx context.Context, id string, req models.UpdateItemRequest, ifUnchanged *models.Item, userToken string) (*models.Item, error) {
+	updates := itemUpdates(req)
+
+	filters := []supabase.Filter{
+		{Column: "id", Operator: supabase.OpEq, Value: id},
+	}
+	if ifUnchanged != nil {
+		filters = append(filters, versionFilters(ifUnchanged)...)
+	}
+
+	var result []models.Item
+	err := r.client.UpdateReturningContext(ctx, "items", updates, filters, &result, userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		if ifUnchanged != nil {
+			return nil, ErrVersionMismatch
+		}
+		return nil, nil
+	}
+
+	return &result[0], nil
+}
+
+// itemUpdates builds the patch for an update request.
+func itemUpdates(req models.UpdateItemRequest) map[string]interface{} {
 	updates := make(map[string]interface{})
 
 	if req.Title != nil {
@@ -87,31 +293,244 @@ func (r *ItemRepository) Update(id string, req models.UpdateItemRequest, userTok
 		updates["completed"] = *req.Completed
 	}
 
+	updates["updated_at"] = time.Now().UTC()
+
+	return updates
+}
+
+// versionFilters matches an item still at the version of item, which is
+// its updated_at, or created_at for items never updated.
+func versionFilters(item *models.Item) []supabase.Filter {
+	if item.UpdatedAt != nil {
+		return []supabase.Filter{
+			{Column: "updated_at", Operator: supabase.OpEq, Value: formatTime(*item.UpdatedAt)},
+		}
+	}
+	return []supabase.Filter{
+		{Column: "updated_at", Operator: supabase.OpIs, Value: "null"},
+		{Column: "created_at", Operator: supabase.OpEq, Value: formatTime(item.CreatedAt)},
+	}
+}
+
+// ListTrash retrieves a user's trashed items, most recently trashed first.
+func (r *ItemRepository) ListTrash(ctx context.Context, userID string, userToken string) ([]models.Item, error) {
+	var items []models.Item
+	err := r.client.From("items").
+		Eq("user_id", userID).
+		Filter("deleted_at", supabase.OpNotIs, "null").
+		Order("deleted_at", false).
+		WithToken(userToken).
+		ExecuteContext(ctx, &items)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Trash moves an item to the trash. The write is conditioned on
+// ifUnchanged like Update.
+func (r *ItemRepository) Trash(ctx context.Context, id string, ifUnchanged *models.Item, userToken string) (*models.Item, error) {
+	now := time.Now().UTC()
+	return r.setDeletedAt(ctx, id, &now, ifUnchanged, userToken)
+}
+
+// Restore takes an item out of the trash.
+func (r *ItemRepository) Restore(ctx context.Context, id string, userToken string) (*models.Item, error) {
+	return r.setDeletedAt(ctx, id, nil, nil, userToken)
+}
+
+// setDeletedAt sets or clears deleted_at, bumping the version so that
+// syncing clients see the change.
+func (r *ItemRepository) setDeletedAt(ctx context.Context, id string, deletedAt *time.Time, ifUnchanged *models.Item, userToken string) (*models.Item, error) {
 	now := time.Now().UTC()
-	updates["updated_at"] = now
+	updates := map[string]interface{}{
+		"deleted_at": deletedAt,
+		"updated_at": now,
+	}
 
 	filters := []supabase.Filter{
 		{Column: "id", Operator: supabase.OpEq, Value: id},
 	}
+	if ifUnchanged != nil {
+		filters = append(filters, versionFilters(ifUnchanged)...)
+	}
 
 	var result []models.Item
-	err := r.client.UpdateReturning("items", updates, filters, &result, userToken)
+	err := r.client.UpdateReturningContext(ctx, "items", updates, filters, &result, userToken)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(result) == 0 {
+		if ifUnchanged != nil {
+			return nil, ErrVersionMismatch
+		}
 		return nil, nil
 	}
 
 	return &result[0], nil
 }
 
-// Delete removes an item by ID.
-func (r *ItemRepository) Delete(id string, userToken string) error {
+// PurgeTrash permanently deletes items of all users trashed before cutoff,
+// leaving tombstones for syncing clients. It runs with the client's own key,
+// so the repository must be created with a service role client, which
+// bypasses row level security. It returns the number of items purged.
+func (r *ItemRepository) PurgeTrash(ctx context.Context, cutoff time.Time) (int, error) {
+	filters := []supabase.Filter{
+		{Column: "deleted_at", Operator: supabase.OpLt, Value: formatTime(cutoff)},
+	}
+
+	var purged []models.Item
+	if err := r.client.DeleteReturningContext(ctx, "items", filters, &purged, ""); err != nil {
+		return 0, err
+	}
+	if len(purged) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now().UTC()
+	tombstones := make([]models.Tombstone, len(purged))
+	for i, item := range purged {
+		tombstones[i] = models.Tombstone{ItemID: item.ID, UserID: item.UserID, DeletedAt: now}
+	}
+
+	if err := r.client.InsertContext(ctx, "item_tombstones", tombstones, ""); err != nil {
+		return len(purged), err
+	}
+
+	return len(purged), nil
+}
+
+// Delete permanently removes an item by ID, leaving a tombstone so that syncing
+// clients learn about the deletion. If ifUnchanged is set, the item is only
+// deleted while still at the version ifUnchanged was read at, and
+// ErrVersionMismatch is returned otherwise.
+func (r *ItemRepository) Delete(ctx context.Context, userID, id string, ifUnchanged *models.Item, userToken string) error {
+	tombstone := map[string]interface{}{
+		"item_id":    id,
+		"user_id":    userID,
+		"deleted_at": time.Now().UTC(),
+	}
+
+	// Record the tombstone first: a tombstone for an item that still exists
+	// is undone below, while a deletion without one would never reach
+	// offline clients.
+	if err := r.client.InsertContext(ctx, "item_tombstones", tombstone, userToken); err != nil {
+		return err
+	}
+
 	filters := []supabase.Filter{
 		{Column: "id", Operator: supabase.OpEq, Value: id},
 	}
+	if ifUnchanged != nil {
+		filters = append(filters, versionFilters(ifUnchanged)...)
+	}
+
+	var deleted []models.Item
+	err := r.client.DeleteReturningContext(ctx, "items", filters, &deleted, userToken)
+	if err == nil && len(deleted) == 0 && ifUnchanged != nil {
+		err = ErrVersionMismatch
+	}
+
+	if err != nil {
+		tombstoneFilters := []supabase.Filter{
+			{Column: "item_id", Operator: supabase.OpEq, Value: id},
+		}
+		_ = r.client.DeleteContext(context.WithoutCancel(ctx), "item_tombstones", tombstoneFilters, userToken)
+		return err
+	}
+
+	return nil
+}
+
+// changeSort orders changed items for sync paging. created_at never
+// changes, so an item updated while a sync is paging keeps its position.
+var changeSort = []SortField{{Column: "created_at"}, {Column: "id"}}
+
+// ChangedSince retrieves one page of a user's items created or updated at
+// or after since, including items moved to or restored from the trash.
+// after, if set, is the Next cursor of the previous page.
+func (r *ItemRepository) ChangedSince(ctx context.Context, userID string, since time.Time, after *Cursor, limit int, userToken string) (*ItemPage, error) {
+	ts := formatTime(since)
+
+	q := r.client.From("items").
+		Eq("user_id", userID).
+		Or(
+			supabase.Condition("created_at", supabase.OpGte, ts),
+			supabase.Condition("updated_at", supabase.OpGte, ts),
+		).
+		WithToken(userToken)
+
+	if after != nil {
+		if err := applyKeyset(q, changeSort, after); err != nil {
+			return nil, err
+		}
+	}
+
+	applyOrder(q, changeSort)
+
+	// Fetch one extra row to learn whether another page follows.
+	var items []models.Item
+	if err := q.Limit(limit+1).ExecuteContext(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	page := &ItemPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		page.Next = &Cursor{
+			Sort:   sortKey(changeSort),
+			Values: []*string{itemColumnValue(last, "created_at"), itemColumnValue(last, "id")},
+		}
+	}
+
+	return page, nil
+}
+
+// TombstonePage is one page of item deletions.
+type TombstonePage struct {
+	Tombstones []models.Tombstone
+	// Next is the cursor for the following page, nil on the last page.
+	Next *Cursor
+}
+
+// tombstoneSort orders deletions for sync paging.
+var tombstoneSort = []SortField{{Column: "deleted_at"}, {Column: "item_id"}}
+
+// TombstonesSince retrieves one page of a user's item deletions at or after
+// since. after, if set, is the Next cursor of the previous page.
+func (r *ItemRepository) TombstonesSince(ctx context.Context, userID string, since time.Time, after *Cursor, limit int, userToken string) (*TombstonePage, error) {
+	q := r.client.From("item_tombstones").
+		Eq("user_id", userID).
+		Filter("deleted_at", supabase.OpGte, formatTime(since)).
+		WithToken(userToken)
+
+	if after != nil {
+		if err := applyKeyset(q, tombstoneSort, after); err != nil {
+			return nil, err
+		}
+	}
+
+	applyOrder(q, tombstoneSort)
+
+	var tombstones []models.Tombstone
+	if err := q.Limit(limit+1).ExecuteContext(ctx, &tombstones); err != nil {
+		return nil, err
+	}
+
+	page := &TombstonePage{Tombstones: tombstones}
+	if len(tombstones) > limit {
+		page.Tombstones = tombstones[:limit]
+		last := page.Tombstones[limit-1]
+		deletedAt := formatTime(last.DeletedAt)
+		page.Next = &Cursor{
+			Sort:   sortKey(tombstoneSort),
+			Values: []*string{&deletedAt, &last.ItemID},
+		}
+	}
 
-	return r.client.Delete("items", filters, userToken)
+	return page, nil
 }
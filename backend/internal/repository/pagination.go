@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+// or was issued for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SortField is one column of a list ordering. Nullable columns sort their
+// NULLs last in either direction.
+type SortField struct {
+	Column     string
+	Descending bool
+	Nullable   bool
+}
+
+// Cursor marks the position after the last row of a page. It holds the
+// value of every sort column of that row, with the row ID last.
+type Cursor struct {
+	Sort   string    `json:"s"`
+	Values []*string `json:"v"`
+}
+
+// Encode returns the opaque, URL-safe form of the cursor.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by Cursor.Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || len(c.Values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// sortKey identifies an ordering so cursors cannot be reused across sorts.
+func sortKey(fields []SortField) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		if f.Descending {
+			parts[i] = "-" + f.Column
+		} else {
+			parts[i] = f.Column
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// applyOrder adds the ordering to the query.
+func applyOrder(q *supabase.QueryBuilder, fields []SortField) {
+	for _, f := range fields {
+		if f.Nullable {
+			q.OrderNullsLast(f.Column, !f.Descending)
+		} else {
+			q.Order(f.Column, !f.Descending)
+		}
+	}
+}
+
+// applyKeyset restricts the query to rows after the cursor position:
+// (a > x) OR (a = x AND b > y) OR ..., with > flipped for descending columns.
+// Since NULLs sort last, a NULL cursor value has nothing strictly after it,
+// and NULL rows come after any non-null value of a nullable column.
+func applyKeyset(q *supabase.QueryBuilder, fields []SortField, cursor *Cursor) error {
+	if cursor.Sort != sortKey(fields) || len(cursor.Values) != len(fields) {
+		return ErrInvalidCursor
+	}
+
+	var branches []string
+	var prefix []string
+	for i, f := range fields {
+		value := cursor.Values[i]
+
+		if value != nil {
+			op := supabase.OpGt
+			if f.Descending {
+				op = supabase.OpLt
+			}
+			branches = append(branches, keysetBranch(prefix, supabase.Condition(f.Column, op, *value)))
+			if f.Nullable {
+				branches = append(branches, keysetBranch(prefix, supabase.Condition(f.Column, supabase.OpIs, "null")))
+			}
+			prefix = append(prefix, supabase.Condition(f.Column, supabase.OpEq, *value))
+		} else {
+			if !f.Nullable {
+				return ErrInvalidCursor
+			}
+			prefix = append(prefix, supabase.Condition(f.Column, supabase.OpIs, "null"))
+		}
+	}
+
+	if len(branches) == 0 {
+		return ErrInvalidCursor
+	}
+
+	q.Or(branches...)
+	return nil
+}
+
+// keysetBranch combines the equality prefix with the strict condition.
+func keysetBranch(prefix []string, cond string) string {
+	if len(prefix) == 0 {
+		return cond
+	}
+	conds := append(append([]string{}, prefix...), cond)
+	return supabase.And(conds...)
+}
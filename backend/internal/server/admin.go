@@ -0,0 +1,219 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	custommw "github.com/{{.ProjectName}}/backend/internal/middleware"
+	"github.com/{{.ProjectName}}/backend/internal/models"
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+)
+
+// adminDefaultPerPage is the page size of user listings that do not ask
+// for one.
+const adminDefaultPerPage = 50
+
+// AdminHandler handles user management requests. Its client must use the
+// service role key.
+type AdminHandler struct {
+	client        *supabase.Client
+	revocations   custommw.RevocationStore
+	revocationTTL time.Duration
+}
+
+// NewAdminHandler creates a new admin handler. The tokens of disabled and
+// deleted users are added to revocations, if set, for revocationTTL.
+func NewAdminHandler(client *supabase.Client, revocations custommw.RevocationStore, revocationTTL time.Duration) *AdminHandler {
+	return &AdminHandler{
+		client:        client,
+		revocations:   revocations,
+		revocationTTL: revocationTTL,
+	}
+}
+
+// revokeUser rejects the access tokens id holds until they expire.
+func (h *AdminHandler) revokeUser(c echo.Context, id string) {
+	if h.revocations == nil {
+		return
+	}
+	until := time.Now().Add(h.revocationTTL)
+	if err := h.revocations.Revoke(c.Request().Context(), custommw.UserRevocationKey(id), until); err != nil {
+		audit(c, "user.revoke_tokens", id, err)
+	}
+}
+
+// adminError maps a GoTrue admin API error to an API response.
+func adminError(c echo.Context, err error, message string) error {
+	switch {
+	case supabase.IsNotFound(err):
+		return NotFound(c, "User not found")
+	case supabase.IsUnavailable(err):
+		return ServiceUnavailable(c, "Auth service temporarily unavailable")
+	}
+	if authErr, ok := supabase.AsAuthAPIError(err); ok {
+		switch authErr.Status {
+		case http.StatusBadRequest, http.StatusUnprocessableEntity:
+			return UnprocessableEntity(c, authErr.Message)
+		case http.StatusConflict:
+			return Conflict(c, authErr.Message)
+		}
+	}
+	return InternalError(c, message)
+}
+
+// ListUsers returns a page of users.
+// GET /api/v1/admin/users?page=1&per_page=50
+func (h *AdminHandler) ListUsers(c echo.Context) error {
+	var req models.AdminListUsersRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest(c, "Invalid query parameters")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	perPage := req.PerPage
+	if perPage == 0 {
+		perPage = adminDefaultPerPage
+	}
+
+	list, err := h.client.ListUsers(c.Request().Context(), page, perPage)
+	audit(c, "user.list", "", err)
+	if err != nil {
+		return adminError(c, err, "Failed to list users")
+	}
+
+	response := models.AdminUserListResponse{
+		Users:   make([]models.AdminUserResponse, len(list.Users)),
+		Page:    page,
+		PerPage: perPage,
+		Total:   list.Total,
+	}
+	for i := range list.Users {
+		response.Users[i] = adminUserResponse(&list.Users[i])
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetUser returns a single user.
+// GET /api/v1/admin/users/:id
+func (h *AdminHandler) GetUser(c echo.Context) error {
+	id := c.Param("id")
+
+	user, err := h.client.GetUser(c.Request().Context(), id)
+	audit(c, "user.get", id, err)
+	if err != nil {
+		return adminError(c, err, "Failed to fetch user")
+	}
+
+	return c.JSON(http.StatusOK, adminUserResponse(user))
+}
+
+// UpdateUser changes a user's email, confirmation, metadata or disables
+// the user.
+// PATCH /api/v1/admin/users/:id
+func (h *AdminHandler) UpdateUser(c echo.Context) error {
+	id := c.Param("id")
+
+	var req models.AdminUpdateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	if req.Disabled != nil && *req.Disabled && id == custommw.GetUserID(c) {
+		return ValidationError(c, map[string]string{"disabled": "You cannot disable your own account"})
+	}
+
+	attrs := supabase.AdminUserAttributes{
+		Email:        req.Email,
+		EmailConfirm: req.EmailConfirm,
+		AppMetadata:  req.AppMetadata,
+		UserMetadata: req.UserMetadata,
+	}
+	if req.Disabled != nil {
+		attrs.BanDuration = supabase.BanNone
+		if *req.Disabled {
+			attrs.BanDuration = supabase.BanForever
+		}
+	}
+
+	user, err := h.client.UpdateUserByID(c.Request().Context(), id, attrs)
+	audit(c, "user.update", id, err)
+	if err != nil {
+		return adminError(c, err, "Failed to update user")
+	}
+	if req.Disabled != nil && *req.Disabled {
+		h.revokeUser(c, id)
+	}
+
+	return c.JSON(http.StatusOK, adminUserResponse(user))
+}
+
+// DeleteUser permanently deletes a user.
+// DELETE /api/v1/admin/users/:id
+func (h *AdminHandler) DeleteUser(c echo.Context) error {
+	id := c.Param("id")
+	if id == custommw.GetUserID(c) {
+		return ValidationError(c, map[string]string{"id": "You cannot delete your own account"})
+	}
+
+	err := h.client.DeleteUser(c.Request().Context(), id)
+	audit(c, "user.delete", id, err)
+	if err != nil {
+		return adminError(c, err, "Failed to delete user")
+	}
+	h.revokeUser(c, id)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// InviteUser creates a user and emails them an invite link.
+// POST /api/v1/admin/users/invite
+func (h *AdminHandler) InviteUser(c echo.Context) error {
+	var req models.InviteUserRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	user, err := h.client.InviteUserByEmail(c.Request().Context(), req.Email, req.RedirectTo, req.Data)
+	targetID := ""
+	if user != nil {
+		targetID = user.ID
+	}
+	audit(c, "user.invite", targetID, err)
+	if err != nil {
+		return adminError(c, err, "Failed to invite user")
+	}
+
+	return c.JSON(http.StatusCreated, adminUserResponse(user))
+}
+
+// adminUserResponse converts a GoTrue user to its admin API form.
+func adminUserResponse(u *supabase.User) models.AdminUserResponse {
+	return models.AdminUserResponse{
+		ID:               u.ID,
+		Email:            u.Email,
+		Phone:            u.Phone,
+		AppMetadata:      u.AppMetadata,
+		UserMetadata:     u.UserMetadata,
+		EmailConfirmedAt: u.EmailConfirmedAt,
+		LastSignInAt:     u.LastSignInAt,
+		BannedUntil:      u.BannedUntil,
+		Disabled:         u.BannedUntil != nil && u.BannedUntil.After(time.Now()),
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	custommw "github.com/{{.ProjectName}}/backend/internal/middleware"
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+	"github.com/{{.ProjectName}}/backend/internal/validation"
+)
+
+func TestListUsersQuery(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantQuery  string
+	}{
+		{query: "", wantStatus: http.StatusOK, wantQuery: "page=1&per_page=50"},
+		{query: "?page=3&per_page=10", wantStatus: http.StatusOK, wantQuery: "page=3&per_page=10"},
+		{query: "?per_page=101", wantStatus: http.StatusBadRequest},
+		{query: "?page=-1", wantStatus: http.StatusBadRequest},
+		{query: "?page=first", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var gotQuery string
+			gotrue := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotQuery = r.URL.RawQuery
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"users":[]}`))
+			}))
+			t.Cleanup(gotrue.Close)
+			h := NewAdminHandler(supabase.NewClient(gotrue.URL, "service-key"), nil, 0)
+
+			e := echo.New()
+			e.Validator = validation.New()
+			e.HTTPErrorHandler = httpErrorHandler(e)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/"+tt.query, nil), rec)
+			if err := h.ListUsers(c); err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if gotQuery != tt.wantQuery {
+				t.Errorf("GoTrue query = %q, want %q", gotQuery, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestAuditDenied(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	e := echo.New()
+	e.DELETE("/users/:id", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) },
+		func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				c.Set("user_id", c.Request().Header.Get("X-User"))
+				c.Set("claims", &custommw.Claims{Sub: c.Request().Header.Get("X-User"), Role: c.Request().Header.Get("X-Role")})
+				return next(c)
+			}
+		},
+		auditDenied("admin.denied", custommw.RequireRoleToWrite(custommw.RoleAdmin)),
+	)
+
+	tests := []struct {
+		name       string
+		role       string
+		wantStatus int
+		wantAudit  bool
+	}{
+		{name: "allowed", role: custommw.RoleAdmin, wantStatus: http.StatusNoContent},
+		{name: "denied", role: custommw.RoleSupport, wantStatus: http.StatusForbidden, wantAudit: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+
+			req := httptest.NewRequest(http.MethodDelete, "/users/user-2", nil)
+			req.Header.Set("X-User", "user-1")
+			req.Header.Set("X-Role", tt.role)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			audited := strings.Contains(logs.String(), `"action":"admin.denied"`)
+			if audited != tt.wantAudit {
+				t.Fatalf("audited = %v, want %v: %s", audited, tt.wantAudit, logs.String())
+			}
+			if tt.wantAudit {
+				for _, want := range []string{`"actor_id":"user-1"`, `"target_id":"user-2"`, `"success":false`, `forbidden: DELETE /users/:id`} {
+					if !strings.Contains(logs.String(), want) {
+						t.Errorf("audit log %s is missing %s", logs.String(), want)
+					}
+				}
+			}
+		})
+	}
+}
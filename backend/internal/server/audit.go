@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	custommw "github.com/{{.ProjectName}}/backend/internal/middleware"
+)
+
+// AuditEntry records a privileged action and who performed it.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	ActorID    string    `json:"actor_id"`
+	ActorEmail string    `json:"actor_email,omitempty"`
+	Action     string    `json:"action"`
+	TargetID   string    `json:"target_id,omitempty"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	RemoteIP   string    `json:"remote_ip"`
+	RequestID  string    `json:"request_id,omitempty"`
+}
+
+// audit writes an audit log line for action on target, performed by the
+// authenticated user. err is the outcome of the action.
+func audit(c echo.Context, action, targetID string, err error) {
+	entry := AuditEntry{
+		Time:       time.Now().UTC(),
+		ActorID:    custommw.GetUserID(c),
+		ActorEmail: custommw.GetUserEmail(c),
+		Action:     action,
+		TargetID:   targetID,
+		Success:    err == nil,
+		RemoteIP:   c.RealIP(),
+		RequestID:  c.Response().Header().Get(echo.HeaderXRequestID),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	data, _ := json.Marshal(entry)
+	log.Printf("audit: %s", data)
+}
+
+// auditDenied wraps an authorization middleware so that the requests it
+// rejects as forbidden are audit-logged as well, as action on the target
+// in the id path parameter.
+func auditDenied(action string, authorize echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			allowed := false
+			err := authorize(func(c echo.Context) error {
+				allowed = true
+				return next(c)
+			})(c)
+
+			if !allowed && c.Response().Status == http.StatusForbidden {
+				audit(c, action, c.Param("id"), errors.New("forbidden: "+c.Request().Method+" "+c.Path()))
+			}
+			return err
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+
+	"github.com/{{.ProjectName}}/backend/internal/validation"
 )
 
 // ErrorResponse represents a standard error response.
@@ -31,6 +33,21 @@ func ValidationError(c echo.Context, errors map[string]string) error {
 	})
 }
 
+// httpErrorHandler renders the validation errors handlers return from
+// c.Validate with ValidationError, and other errors as echo does.
+func httpErrorHandler(e *echo.Echo) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		details, ok := validation.Errors(c, err)
+		if !ok || c.Response().Committed {
+			e.DefaultHTTPErrorHandler(err, c)
+			return
+		}
+		if err := ValidationError(c, details); err != nil {
+			e.Logger.Error(err)
+		}
+	}
+}
+
 // Unauthorized returns a 401 Unauthorized response.
 func Unauthorized(c echo.Context, message string) error {
 	return c.JSON(http.StatusUnauthorized, ErrorResponse{
@@ -55,6 +72,46 @@ func NotFound(c echo.Context, message string) error {
 	})
 }
 
+// Conflict returns a 409 Conflict response.
+func Conflict(c echo.Context, message string) error {
+	return c.JSON(http.StatusConflict, ErrorResponse{
+		Code:    "conflict",
+		Message: message,
+	})
+}
+
+// PreconditionFailed returns a 412 Precondition Failed response.
+func PreconditionFailed(c echo.Context, message string) error {
+	return c.JSON(http.StatusPreconditionFailed, ErrorResponse{
+		Code:    "precondition_failed",
+		Message: message,
+	})
+}
+
+// UnprocessableEntity returns a 422 Unprocessable Entity response.
+func UnprocessableEntity(c echo.Context, message string) error {
+	return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+		Code:    "unprocessable_entity",
+		Message: message,
+	})
+}
+
+// TooManyRequests returns a 429 Too Many Requests response.
+func TooManyRequests(c echo.Context, message string) error {
+	return c.JSON(http.StatusTooManyRequests, ErrorResponse{
+		Code:    "too_many_requests",
+		Message: message,
+	})
+}
+
+// ServiceUnavailable returns a 503 Service Unavailable response.
+func ServiceUnavailable(c echo.Context, message string) error {
+	return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+		Code:    "service_unavailable",
+		Message: message,
+	})
+}
+
 // InternalError returns a 500 Internal Server Error response.
 func InternalError(c echo.Context, message string) error {
 	return c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/{{.ProjectName}}/backend/internal/models"
+)
+
+// itemETag returns the entity tag of an item, derived from its version.
+func itemETag(item *models.Item) string {
+	return `"` + strconv.FormatInt(item.Version().UnixNano(), 36) + `"`
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header value
+// matches etag. With weak, as for If-None-Match, weak tags compare equal to
+// their strong form; otherwise, as If-Match requires (RFC 7232), they never
+// match.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package server
+
+import "testing"
+
+func TestETagMatches(t *testing.T) {
+	const etag = `"abc"`
+
+	tests := []struct {
+		header string
+		weak   bool
+		want   bool
+	}{
+		{header: `"abc"`, want: true},
+		{header: `"xyz", "abc"`, want: true},
+		{header: `*`, want: true},
+		{header: `"xyz"`, want: false},
+		{header: `W/"abc"`, want: false},
+		{header: `W/"abc"`, weak: true, want: true},
+		{header: `"xyz", W/"abc"`, weak: true, want: true},
+		{header: `W/"xyz"`, weak: true, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag, tt.weak); got != tt.want {
+			t.Errorf("etagMatches(%q, weak=%v) = %v, want %v", tt.header, tt.weak, got, tt.want)
+		}
+	}
+}
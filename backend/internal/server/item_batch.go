@@ -0,0 +1,150 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	custommw "github.com/{{.ProjectName}}/backend/internal/middleware"
+	"github.com/{{.ProjectName}}/backend/internal/models"
+)
+
+// BatchItems creates, updates or deletes several items in one call and
+// reports a status per item, in request order.
+// POST /api/v1/items/batch
+func (h *ItemHandler) BatchItems(c echo.Context) error {
+	userID := custommw.GetUserID(c)
+	if userID == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	var req models.BatchRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	var results []models.BatchResult
+	var err error
+	if req.Op == models.BatchCreate {
+		results, err = h.batchCreate(c, userID, req.Items)
+	} else {
+		results, err = h.batchModify(c, userID, req)
+	}
+	if err != nil {
+		return itemError(c, err, "Failed to apply batch")
+	}
+
+	return c.JSON(http.StatusOK, models.BatchResponse{Results: results})
+}
+
+// batchCreate inserts the items with one request.
+func (h *ItemHandler) batchCreate(c echo.Context, userID string, items []models.CreateItemRequest) ([]models.BatchResult, error) {
+	created, err := h.repo.CreateMany(c.Request().Context(), userID, items, getToken(c))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.BatchResult, len(items))
+	for i := range items {
+		results[i].Index = i
+		if i >= len(created) {
+			results[i].Status = http.StatusInternalServerError
+			results[i].Error = "Item was not created"
+			continue
+		}
+		resp := created[i].ToResponse()
+		results[i].ID = created[i].ID
+		results[i].Status = http.StatusCreated
+		results[i].Item = &resp
+	}
+
+	return results, nil
+}
+
+// batchModify updates or deletes the items the user owns, checking
+// ownership of every ID with a single query.
+func (h *ItemHandler) batchModify(c echo.Context, userID string, req models.BatchRequest) ([]models.BatchResult, error) {
+	ctx := c.Request().Context()
+	token := getToken(c)
+
+	existing, err := h.repo.GetByIDs(ctx, req.IDs, token)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]*models.Item, len(existing))
+	for i := range existing {
+		found[existing[i].ID] = &existing[i]
+	}
+
+	results := make([]models.BatchResult, len(req.IDs))
+	var owned []string
+	seen := make(map[string]bool)
+
+	for i, id := range req.IDs {
+		results[i] = models.BatchResult{Index: i, ID: id}
+		item, ok := found[id]
+
+		switch {
+		case seen[id]:
+			results[i].Status = http.StatusConflict
+			results[i].Error = "Duplicate id"
+		case !ok || (item.IsTrashed() && !(req.Op == models.BatchDelete && req.Permanent)):
+			results[i].Status = http.StatusNotFound
+			results[i].Error = "Item not found"
+		case item.UserID != userID:
+			results[i].Status = http.StatusForbidden
+			results[i].Error = "Access denied"
+		default:
+			owned = append(owned, id)
+		}
+		seen[id] = true
+	}
+
+	if len(owned) == 0 {
+		return results, nil
+	}
+
+	var changed []models.Item
+	switch {
+	case req.Op == models.BatchUpdate:
+		changed, err = h.repo.UpdateMany(ctx, userID, owned, *req.Changes, token)
+	case req.Permanent:
+		changed, err = h.repo.DeleteMany(ctx, userID, owned, token)
+	default:
+		changed, err = h.repo.TrashMany(ctx, userID, owned, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[string]*models.Item, len(changed))
+	for i := range changed {
+		applied[changed[i].ID] = &changed[i]
+	}
+
+	for i := range results {
+		if results[i].Status != 0 {
+			continue
+		}
+		item, ok := applied[results[i].ID]
+		switch {
+		case !ok:
+			// Removed between the ownership check and the write.
+			results[i].Status = http.StatusNotFound
+			results[i].Error = "Item not found"
+		case req.Op == models.BatchUpdate:
+			resp := item.ToResponse()
+			results[i].Status = http.StatusOK
+			results[i].Item = &resp
+		default:
+			results[i].Status = http.StatusNoContent
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/{{.ProjectName}}/backend/internal/repository"
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+)
+
+func TestBatchItemsRejectsMalformedIDs(t *testing.T) {
+	supabaseServer := newFakeGoTrue(t, "")
+	h := NewItemHandler(repository.NewItemRepository(supabase.NewClient(supabaseServer.URL, "anon-key")))
+
+	rec := serveAsUser(h.BatchItems, `{"op":"delete","ids":["5f0c2b7e-8d0a-4c55-9a43-0d6f3a1b2c3d","42"]}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body.String())
+	}
+	if got := errorDetails(t, rec)["ids[1]"]; got == "" {
+		t.Errorf("details = %s, want an error for ids[1]", rec.Body.String())
+	}
+	if len(supabaseServer.requests) != 0 {
+		t.Errorf("Supabase received %v, want no requests", supabaseServer.requests)
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/{{.ProjectName}}/backend/internal/repository"
+)
+
+// Pagination limits for item lists.
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+// parseListOptions reads the item list query parameters:
+//
+//	limit=50&cursor=...&completed=false&q=groceries
+//	&sort=-updated_at,title&created_after=2024-01-01T00:00:00Z
+//
+// It returns field-level errors for unknown or malformed parameters.
+func parseListOptions(c echo.Context) (repository.ListOptions, map[string]string) {
+	opts := repository.ListOptions{Limit: defaultPageSize}
+	errors := make(map[string]string)
+
+	for name, values := range c.QueryParams() {
+		raw := values[0]
+
+		switch name {
+		case "limit":
+			limit, err := strconv.Atoi(raw)
+			if err != nil || limit < 1 || limit > maxPageSize {
+				errors[name] = fmt.Sprintf("Limit must be between 1 and %d", maxPageSize)
+				continue
+			}
+			opts.Limit = limit
+
+		case "cursor":
+			cursor, err := repository.DecodeCursor(raw)
+			if err != nil {
+				errors[name] = "Cursor is invalid"
+				continue
+			}
+			opts.Cursor = cursor
+
+		case "completed":
+			completed, err := strconv.ParseBool(raw)
+			if err != nil {
+				errors[name] = "Completed must be true or false"
+				continue
+			}
+			opts.Filter.Completed = &completed
+
+		case "q":
+			opts.Filter.Search = strings.TrimSpace(raw)
+
+		case "sort":
+			sort, msg := parseSort(raw)
+			if msg != "" {
+				errors[name] = msg
+				continue
+			}
+			opts.Sort = sort
+
+		case "created_after", "created_before", "updated_after", "updated_before":
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				errors[name] = "Must be an RFC 3339 timestamp"
+				continue
+			}
+			switch name {
+			case "created_after":
+				opts.Filter.CreatedAfter = &t
+			case "created_before":
+				opts.Filter.CreatedBefore = &t
+			case "updated_after":
+				opts.Filter.UpdatedAfter = &t
+			case "updated_before":
+				opts.Filter.UpdatedBefore = &t
+			}
+
+		default:
+			errors[name] = "Unknown query parameter"
+		}
+	}
+
+	return opts, errors
+}
+
+// parseSort parses a comma-separated list of sortable columns, each
+// optionally prefixed with '-' for descending order.
+func parseSort(raw string) ([]repository.SortField, string) {
+	var fields []repository.SortField
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		field := repository.SortField{Column: strings.TrimPrefix(part, "-")}
+		field.Descending = field.Column != part
+
+		if !repository.IsItemSortColumn(field.Column) {
+			return nil, fmt.Sprintf("Cannot sort by %q", field.Column)
+		}
+		if seen[field.Column] {
+			return nil, fmt.Sprintf("Duplicate sort field %q", field.Column)
+		}
+		seen[field.Column] = true
+		fields = append(fields, field)
+	}
+
+	return fields, ""
+}
@@ -2,7 +2,10 @@
 package server
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/labstack/echo/v4"
@@ -10,6 +13,7 @@ import (
 	custommw "github.com/{{.ProjectName}}/backend/internal/middleware"
 	"github.com/{{.ProjectName}}/backend/internal/models"
 	"github.com/{{.ProjectName}}/backend/internal/repository"
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
 )
 
 // ItemHandler handles item-related requests.
@@ -31,29 +35,96 @@ func getToken(c echo.Context) string {
 	return ""
 }
 
-// ListItems returns all items for the authenticated user.
-// GET /api/v1/items
+// itemError translates a repository error into the matching HTTP response,
+// falling back to a 500 with message for unrecognized failures.
+func itemError(c echo.Context, err error, message string) error {
+	switch {
+	case err == repository.ErrVersionMismatch:
+		return PreconditionFailed(c, "Item was modified")
+	case supabase.IsNotFound(err):
+		return NotFound(c, "Item not found")
+	case supabase.IsRLSDenied(err):
+		return Forbidden(c, "Access denied")
+	case supabase.IsUniqueViolation(err):
+		return Conflict(c, "Item already exists")
+	case supabase.IsForeignKeyViolation(err), supabase.IsConstraintViolation(err):
+		return UnprocessableEntity(c, "Item data is invalid")
+	case supabase.IsUnavailable(err):
+		return ServiceUnavailable(c, "Database temporarily unavailable")
+	default:
+		return InternalError(c, message)
+	}
+}
+
+// ListItems returns a page of items for the authenticated user.
+// GET /api/v1/items?limit=50&cursor=...&completed=false&sort=-updated_at&q=...
 func (h *ItemHandler) ListItems(c echo.Context) error {
 	userID := custommw.GetUserID(c)
 	if userID == "" {
 		return Unauthorized(c, "User not authenticated")
 	}
 
+	opts, errors := parseListOptions(c)
+	if len(errors) > 0 {
+		return ValidationError(c, errors)
+	}
+
 	token := getToken(c)
-	items, err := h.repo.GetByUserID(userID, token)
+	page, err := h.repo.ListByUserID(c.Request().Context(), userID, opts, token)
+	if err == repository.ErrInvalidCursor {
+		return ValidationError(c, map[string]string{"cursor": "Cursor is invalid"})
+	}
 	if err != nil {
-		return InternalError(c, "Failed to fetch items")
+		return itemError(c, err, "Failed to fetch items")
 	}
 
 	// Convert to response format
-	response := make([]models.ItemResponse, len(items))
-	for i, item := range items {
-		response[i] = item.ToResponse()
+	response := models.ItemListResponse{
+		Items: make([]models.ItemResponse, len(page.Items)),
+	}
+	for i, item := range page.Items {
+		response.Items[i] = item.ToResponse()
+	}
+
+	if page.Next != nil {
+		response.NextCursor = page.Next.Encode()
+		response.HasMore = true
+		c.Response().Header().Set("Link", nextPageLink(c, response.NextCursor))
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
+// nextPageLink builds an RFC 8288 Link header pointing at the next page,
+// keeping every other query parameter of the current request.
+func nextPageLink(c echo.Context, cursor string) string {
+	query := c.Request().URL.Query()
+	query.Set("cursor", cursor)
+
+	next := url.URL{
+		Scheme:   c.Scheme(),
+		Host:     c.Request().Host,
+		Path:     c.Request().URL.Path,
+		RawQuery: query.Encode(),
+	}
+
+	return fmt.Sprintf("<%s>; rel=\"next\"", next.String())
+}
+
+// checkIfMatch evaluates the If-Match header against the current item. It
+// returns false if the precondition fails, and otherwise the item the write
+// must be conditioned on, or nil for unconditional writes.
+func checkIfMatch(c echo.Context, existing *models.Item) (*models.Item, bool) {
+	match := c.Request().Header.Get("If-Match")
+	if match == "" || strings.TrimSpace(match) == "*" {
+		return nil, true
+	}
+	if !etagMatches(match, itemETag(existing), false) {
+		return nil, false
+	}
+	return existing, true
+}
+
 // GetItem returns a single item by ID.
 // GET /api/v1/items/:id
 func (h *ItemHandler) GetItem(c echo.Context) error {
@@ -68,15 +139,24 @@ func (h *ItemHandler) GetItem(c echo.Context) error {
 	}
 
 	token := getToken(c)
-	item, err := h.repo.GetByID(id, token)
+	item, err := h.repo.GetByID(c.Request().Context(), id, token)
 	if err != nil {
-		return NotFound(c, "Item not found")
+		return itemError(c, err, "Failed to fetch item")
 	}
 
 	// Verify ownership
 	if item.UserID != userID {
 		return Forbidden(c, "Access denied")
 	}
+	if item.IsTrashed() {
+		return NotFound(c, "Item not found")
+	}
+
+	etag := itemETag(item)
+	c.Response().Header().Set("ETag", etag)
+	if match := c.Request().Header.Get("If-None-Match"); match != "" && etagMatches(match, etag, true) {
+		return c.NoContent(http.StatusNotModified)
+	}
 
 	return c.JSON(http.StatusOK, item.ToResponse())
 }
@@ -93,22 +173,17 @@ func (h *ItemHandler) CreateItem(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return BadRequest(c, "Invalid request body")
 	}
-
-	// Validate
-	errors := make(map[string]string)
-	if req.Title == "" {
-		errors["title"] = "Title is required"
-	}
-	if len(errors) > 0 {
-		return ValidationError(c, errors)
+	if err := c.Validate(&req); err != nil {
+		return err
 	}
 
 	token := getToken(c)
-	item, err := h.repo.Create(userID, req, token)
+	item, err := h.repo.Create(c.Request().Context(), userID, req, token)
 	if err != nil {
-		return InternalError(c, "Failed to create item")
+		return itemError(c, err, "Failed to create item")
 	}
 
+	c.Response().Header().Set("ETag", itemETag(item))
 	return c.JSON(http.StatusCreated, item.ToResponse())
 }
 
@@ -128,28 +203,41 @@ func (h *ItemHandler) UpdateItem(c echo.Context) error {
 	token := getToken(c)
 
 	// Verify item exists and belongs to user
-	existing, err := h.repo.GetByID(id, token)
+	existing, err := h.repo.GetByID(c.Request().Context(), id, token)
 	if err != nil {
-		return NotFound(c, "Item not found")
+		return itemError(c, err, "Failed to fetch item")
 	}
 	if existing.UserID != userID {
 		return Forbidden(c, "Access denied")
 	}
+	if existing.IsTrashed() {
+		return NotFound(c, "Item not found")
+	}
+
+	ifUnchanged, ok := checkIfMatch(c, existing)
+	if !ok {
+		return PreconditionFailed(c, "Item was modified")
+	}
 
 	var req models.UpdateItemRequest
 	if err := c.Bind(&req); err != nil {
 		return BadRequest(c, "Invalid request body")
 	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
 
-	item, err := h.repo.Update(id, req, token)
+	item, err := h.repo.Update(c.Request().Context(), id, req, ifUnchanged, token)
 	if err != nil {
-		return InternalError(c, "Failed to update item")
+		return itemError(c, err, "Failed to update item")
 	}
 
+	c.Response().Header().Set("ETag", itemETag(item))
 	return c.JSON(http.StatusOK, item.ToResponse())
 }
 
-// DeleteItem removes an item.
+// DeleteItem moves an item to the trash, or removes it for good with
+// ?permanent=true. Trashed items can only be deleted permanently.
 // DELETE /api/v1/items/:id
 func (h *ItemHandler) DeleteItem(c echo.Context) error {
 	userID := custommw.GetUserID(c)
@@ -162,20 +250,103 @@ func (h *ItemHandler) DeleteItem(c echo.Context) error {
 		return BadRequest(c, "Item ID is required")
 	}
 
+	permanent := false
+	if raw := c.QueryParam("permanent"); raw != "" {
+		var err error
+		if permanent, err = strconv.ParseBool(raw); err != nil {
+			return ValidationError(c, map[string]string{"permanent": "Permanent must be true or false"})
+		}
+	}
+
 	token := getToken(c)
 
 	// Verify item exists and belongs to user
-	existing, err := h.repo.GetByID(id, token)
+	existing, err := h.repo.GetByID(c.Request().Context(), id, token)
 	if err != nil {
-		return NotFound(c, "Item not found")
+		return itemError(c, err, "Failed to fetch item")
 	}
 	if existing.UserID != userID {
 		return Forbidden(c, "Access denied")
 	}
+	if existing.IsTrashed() && !permanent {
+		return NotFound(c, "Item not found")
+	}
 
-	if err := h.repo.Delete(id, token); err != nil {
-		return InternalError(c, "Failed to delete item")
+	ifUnchanged, ok := checkIfMatch(c, existing)
+	if !ok {
+		return PreconditionFailed(c, "Item was modified")
+	}
+
+	if permanent {
+		err = h.repo.Delete(c.Request().Context(), userID, id, ifUnchanged, token)
+	} else {
+		_, err = h.repo.Trash(c.Request().Context(), id, ifUnchanged, token)
+	}
+	if err != nil {
+		return itemError(c, err, "Failed to delete item")
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+// ListTrash returns the authenticated user's trashed items.
+// GET /api/v1/items/trash
+func (h *ItemHandler) ListTrash(c echo.Context) error {
+	userID := custommw.GetUserID(c)
+	if userID == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	token := getToken(c)
+	items, err := h.repo.ListTrash(c.Request().Context(), userID, token)
+	if err != nil {
+		return itemError(c, err, "Failed to fetch trash")
+	}
+
+	// Convert to response format
+	response := make([]models.ItemResponse, len(items))
+	for i, item := range items {
+		response[i] = item.ToResponse()
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// RestoreItem takes an item out of the trash.
+// POST /api/v1/items/:id/restore
+func (h *ItemHandler) RestoreItem(c echo.Context) error {
+	userID := custommw.GetUserID(c)
+	if userID == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		return BadRequest(c, "Item ID is required")
+	}
+
+	token := getToken(c)
+
+	// Verify item is trashed and belongs to user
+	existing, err := h.repo.GetByID(c.Request().Context(), id, token)
+	if err != nil {
+		return itemError(c, err, "Failed to fetch item")
+	}
+	if existing.UserID != userID {
+		return Forbidden(c, "Access denied")
+	}
+	if !existing.IsTrashed() {
+		return Conflict(c, "Item is not in the trash")
+	}
+
+	item, err := h.repo.Restore(c.Request().Context(), id, token)
+	if err != nil {
+		return itemError(c, err, "Failed to restore item")
+	}
+	if item == nil {
+		return NotFound(c, "Item not found")
+	}
+
+	c.Response().Header().Set("ETag", itemETag(item))
+	return c.JSON(http.StatusOK, item.ToResponse())
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/{{.ProjectName}}/backend/internal/auth"
+	custommw "github.com/{{.ProjectName}}/backend/internal/middleware"
+	"github.com/{{.ProjectName}}/backend/internal/models"
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+)
+
+// MFAHandler handles TOTP factor enrollment and verification for the
+// authenticated user.
+type MFAHandler struct {
+	client   *supabase.Client
+	issuer   string
+	sessions auth.SessionStore
+}
+
+// NewMFAHandler creates a new MFA handler. issuer is the name shown in
+// authenticator apps; GoTrue uses its site URL when empty. Sessions
+// verified with a factor are recorded in sessions.
+func NewMFAHandler(client *supabase.Client, issuer string, sessions auth.SessionStore) *MFAHandler {
+	return &MFAHandler{client: client, issuer: issuer, sessions: sessions}
+}
+
+// mfaError maps a GoTrue MFA API error to an API response.
+func mfaError(c echo.Context, err error, message string) error {
+	if supabase.IsUnavailable(err) {
+		return ServiceUnavailable(c, "Auth service temporarily unavailable")
+	}
+	if authErr, ok := supabase.AsAuthAPIError(err); ok {
+		switch authErr.Status {
+		case http.StatusNotFound:
+			return NotFound(c, "Factor not found")
+		case http.StatusBadRequest, http.StatusUnprocessableEntity:
+			return UnprocessableEntity(c, authErr.Message)
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return Forbidden(c, authErr.Message)
+		}
+	}
+	return InternalError(c, message)
+}
+
+// ListFactors returns the user's enrolled factors.
+// GET /api/v1/mfa/factors
+func (h *MFAHandler) ListFactors(c echo.Context) error {
+	if custommw.GetUserID(c) == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	user, err := h.client.CurrentUser(c.Request().Context(), getToken(c))
+	if err != nil {
+		return mfaError(c, err, "Failed to fetch factors")
+	}
+
+	factors := make([]models.FactorResponse, len(user.Factors))
+	for i, f := range user.Factors {
+		factors[i] = models.FactorResponse{
+			ID:           f.ID,
+			FriendlyName: f.FriendlyName,
+			FactorType:   f.FactorType,
+			Status:       f.Status,
+			CreatedAt:    f.CreatedAt,
+		}
+	}
+
+	return c.JSON(http.StatusOK, models.FactorListResponse{Factors: factors})
+}
+
+// EnrollFactor enrolls a new TOTP factor. It stays unverified until a code
+// from it is verified.
+// POST /api/v1/mfa/factors
+func (h *MFAHandler) EnrollFactor(c echo.Context) error {
+	if custommw.GetUserID(c) == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	var req models.EnrollFactorRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	enrollment, err := h.client.EnrollTOTP(c.Request().Context(), getToken(c), req.FriendlyName, h.issuer)
+	if err != nil {
+		return mfaError(c, err, "Failed to enroll factor")
+	}
+
+	return c.JSON(http.StatusCreated, models.EnrollFactorResponse{
+		ID:           enrollment.ID,
+		FriendlyName: enrollment.FriendlyName,
+		URI:          enrollment.TOTP.URI,
+		QRCode:       enrollment.TOTP.QRCode,
+		Secret:       enrollment.TOTP.Secret,
+	})
+}
+
+// ChallengeFactor creates a challenge for a factor.
+// POST /api/v1/mfa/factors/:id/challenge
+func (h *MFAHandler) ChallengeFactor(c echo.Context) error {
+	if custommw.GetUserID(c) == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	challenge, err := h.client.ChallengeFactor(c.Request().Context(), getToken(c), c.Param("id"))
+	if err != nil {
+		return mfaError(c, err, "Failed to create challenge")
+	}
+
+	return c.JSON(http.StatusCreated, models.ChallengeResponse{
+		ID:        challenge.ID,
+		ExpiresAt: time.Unix(challenge.ExpiresAt, 0).UTC(),
+	})
+}
+
+// VerifyFactor verifies a code from a factor and returns a new session at
+// assurance level aal2.
+// POST /api/v1/mfa/factors/:id/verify
+func (h *MFAHandler) VerifyFactor(c echo.Context) error {
+	if custommw.GetUserID(c) == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	var req models.VerifyFactorRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	token := getToken(c)
+	factorID := c.Param("id")
+
+	challengeID := req.ChallengeID
+	if challengeID == "" {
+		challenge, err := h.client.ChallengeFactor(ctx, token, factorID)
+		if err != nil {
+			return mfaError(c, err, "Failed to create challenge")
+		}
+		challengeID = challenge.ID
+	}
+
+	resp, err := h.client.VerifyFactor(ctx, token, factorID, challengeID, req.Code)
+	if err != nil {
+		return mfaError(c, err, "Failed to verify code")
+	}
+
+	auth.TrackSession(c, h.sessions, resp)
+	return c.JSON(http.StatusOK, auth.NewAuthResponse(resp))
+}
+
+// UnenrollFactor removes a factor. Removing a verified factor needs an
+// aal2 session.
+// DELETE /api/v1/mfa/factors/:id
+func (h *MFAHandler) UnenrollFactor(c echo.Context) error {
+	if custommw.GetUserID(c) == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	if err := h.client.UnenrollFactor(c.Request().Context(), getToken(c), c.Param("id")); err != nil {
+		return mfaError(c, err, "Failed to remove factor")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
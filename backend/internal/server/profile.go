@@ -0,0 +1,217 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/{{.ProjectName}}/backend/internal/auth"
+	custommw "github.com/{{.ProjectName}}/backend/internal/middleware"
+	"github.com/{{.ProjectName}}/backend/internal/models"
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+)
+
+// ProfileHandler handles requests about the current user's account.
+type ProfileHandler struct {
+	client   *supabase.Client
+	policy   auth.Policy
+	throttle auth.LoginThrottle
+}
+
+// NewProfileHandler creates a new profile handler enforcing the password
+// and email rules of policy. Current password checks count as sign-in
+// attempts of throttle, which should share its store with the auth handler.
+func NewProfileHandler(client *supabase.Client, policy auth.Policy, throttle auth.LoginThrottle) *ProfileHandler {
+	if throttle.Store == nil {
+		throttle.Store = auth.NewMemoryAttemptStore()
+	}
+	return &ProfileHandler{client: client, policy: policy, throttle: throttle}
+}
+
+// profileError maps a GoTrue user API error to an API response.
+func profileError(c echo.Context, err error, message string) error {
+	if supabase.IsUnavailable(err) {
+		return ServiceUnavailable(c, "Auth service temporarily unavailable")
+	}
+	if authErr, ok := supabase.AsAuthAPIError(err); ok {
+		switch authErr.Status {
+		case http.StatusBadRequest, http.StatusUnprocessableEntity:
+			return UnprocessableEntity(c, authErr.Message)
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return Unauthorized(c, "Session is no longer valid")
+		}
+	}
+	return InternalError(c, message)
+}
+
+// GetProfile returns the current user.
+// GET /api/v1/me
+func (h *ProfileHandler) GetProfile(c echo.Context) error {
+	if custommw.GetUserID(c) == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	user, err := h.client.CurrentUser(c.Request().Context(), getToken(c))
+	if err != nil {
+		return profileError(c, err, "Failed to fetch profile")
+	}
+
+	return c.JSON(http.StatusOK, auth.NewUser(user))
+}
+
+// UpdateProfile changes the current user's display name, avatar URL,
+// locale or timezone.
+// PATCH /api/v1/me
+func (h *ProfileHandler) UpdateProfile(c echo.Context) error {
+	if custommw.GetUserID(c) == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	var req models.UpdateProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	data := make(map[string]interface{})
+	if req.DisplayName != nil {
+		data[auth.MetadataDisplayName] = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.AvatarURL != nil {
+		data[auth.MetadataAvatarURL] = *req.AvatarURL
+	}
+	if req.Locale != nil {
+		data[auth.MetadataLocale] = *req.Locale
+	}
+	if req.Timezone != nil {
+		data[auth.MetadataTimezone] = *req.Timezone
+	}
+	if len(data) == 0 {
+		return ValidationError(c, map[string]string{"body": "At least one field is required"})
+	}
+
+	user, err := h.client.UpdateUser(c.Request().Context(), getToken(c), supabase.UserAttributes{Data: data})
+	if err != nil {
+		return profileError(c, err, "Failed to update profile")
+	}
+
+	return c.JSON(http.StatusOK, auth.NewUser(user))
+}
+
+// ChangeEmail starts a change of the current user's email. GoTrue emails a
+// confirmation link; the email changes once it is followed.
+// POST /api/v1/me/email
+func (h *ProfileHandler) ChangeEmail(c echo.Context) error {
+	if custommw.GetUserID(c) == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	var req models.ChangeEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	if msg := h.policy.CheckEmail(req.Email); msg != "" {
+		return ValidationError(c, map[string]string{"email": msg})
+	}
+
+	if ok, err := h.checkPassword(c, req.CurrentPassword); !ok {
+		return err
+	}
+
+	email := req.Email
+	if _, err := h.client.UpdateUser(c.Request().Context(), getToken(c), supabase.UserAttributes{Email: &email}); err != nil {
+		return profileError(c, err, "Failed to change email")
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"message": "A confirmation link has been sent to the new email",
+	})
+}
+
+// ChangePassword changes the current user's password.
+// POST /api/v1/me/password
+func (h *ProfileHandler) ChangePassword(c echo.Context) error {
+	if custommw.GetUserID(c) == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	// Validate
+	details := make(map[string]string)
+	if msg := h.policy.CheckPassword(req.NewPassword); msg != "" {
+		details["new_password"] = msg
+	} else if req.NewPassword == req.CurrentPassword {
+		details["new_password"] = "New password must differ from the current one"
+	}
+	if len(details) > 0 {
+		return ValidationError(c, details)
+	}
+
+	if ok, err := h.checkPassword(c, req.CurrentPassword); !ok {
+		return err
+	}
+
+	password := req.NewPassword
+	if _, err := h.client.UpdateUser(c.Request().Context(), getToken(c), supabase.UserAttributes{Password: &password}); err != nil {
+		return profileError(c, err, "Failed to change password")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Password has been changed",
+	})
+}
+
+// checkPassword verifies the current user's password by signing in with it.
+// It reports false after writing the error response if that fails, in
+// which case the caller must return err. The check counts as a sign-in
+// attempt of the login throttle, and the extra session is ended right away.
+func (h *ProfileHandler) checkPassword(c echo.Context, password string) (bool, error) {
+	email := custommw.GetUserEmail(c)
+	if email == "" {
+		return false, UnprocessableEntity(c, "Account has no email to verify the password with")
+	}
+
+	ctx := c.Request().Context()
+	ip := c.RealIP()
+
+	// The attempt counts as failed until the sign-in succeeds
+	wait, err := h.throttle.Attempt(ctx, email, ip)
+	if err != nil {
+		return false, ServiceUnavailable(c, "Password check temporarily unavailable")
+	}
+	if wait > 0 {
+		seconds := int((wait + time.Second - 1) / time.Second)
+		c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(seconds))
+		return false, TooManyRequests(c, "Too many failed password attempts, try again later")
+	}
+
+	session, err := h.client.SignInContext(ctx, email, password)
+	if supabase.IsUnavailable(err) {
+		_ = h.throttle.Aborted(ctx, email, ip)
+		return false, ServiceUnavailable(c, "Auth service temporarily unavailable")
+	}
+	if err != nil {
+		return false, ValidationError(c, map[string]string{"current_password": "Current password is incorrect"})
+	}
+
+	_ = h.throttle.Succeeded(ctx, email, ip)
+	_ = h.client.SignOutScope(ctx, session.AccessToken, supabase.LogoutLocal)
+	return true, nil
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/{{.ProjectName}}/backend/internal/auth"
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+	"github.com/{{.ProjectName}}/backend/internal/validation"
+)
+
+// fakeGoTrue is an httptest stand-in for GoTrue that accepts password
+// sign-ins with password and records the requests it receives.
+type fakeGoTrue struct {
+	*httptest.Server
+	password string
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func newFakeGoTrue(t *testing.T, password string) *fakeGoTrue {
+	t.Helper()
+
+	f := &fakeGoTrue{password: password}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+		f.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/auth/v1/token" && !strings.Contains(string(body), `"password":"`+f.password+`"`):
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid login credentials"}`))
+		case r.URL.Path == "/auth/v1/token":
+			_, _ = w.Write([]byte(`{"access_token":"checked","refresh_token":"refresh","expires_in":3600,"token_type":"bearer","user":{"id":"user-1","email":"a@example.com"}}`))
+		case r.URL.Path == "/auth/v1/user":
+			_, _ = w.Write([]byte(`{"id":"user-1","email":"a@example.com"}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// called reports whether GoTrue received a request for method and path.
+func (f *fakeGoTrue) called(method, path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, req := range f.requests {
+		if req == method+" "+path {
+			return true
+		}
+	}
+	return false
+}
+
+// serveAsUser calls handler as the signed-in user a@example.com with a JSON
+// request body and returns the response.
+func serveAsUser(handler echo.HandlerFunc, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.Validator = validation.New()
+	e.HTTPErrorHandler = httpErrorHandler(e)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer access")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user_id", "user-1")
+	c.Set("user_email", "a@example.com")
+	if err := handler(c); err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
+	return rec
+}
+
+func TestProfileChangesNeedCurrentPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(h *ProfileHandler) echo.HandlerFunc
+		body    string
+		status  int
+	}{
+		{
+			name:    "change password with wrong password",
+			handler: func(h *ProfileHandler) echo.HandlerFunc { return h.ChangePassword },
+			body:    `{"current_password":"wrong","new_password":"Another-passw0rd"}`,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "change email with wrong password",
+			handler: func(h *ProfileHandler) echo.HandlerFunc { return h.ChangeEmail },
+			body:    `{"email":"b@example.com","current_password":"wrong"}`,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "change password",
+			handler: func(h *ProfileHandler) echo.HandlerFunc { return h.ChangePassword },
+			body:    `{"current_password":"Correct-passw0rd","new_password":"Another-passw0rd"}`,
+			status:  http.StatusOK,
+		},
+		{
+			name:    "change email",
+			handler: func(h *ProfileHandler) echo.HandlerFunc { return h.ChangeEmail },
+			body:    `{"email":"b@example.com","current_password":"Correct-passw0rd"}`,
+			status:  http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotrue := newFakeGoTrue(t, "Correct-passw0rd")
+			h := NewProfileHandler(supabase.NewClient(gotrue.URL, "anon-key"), auth.Policy{}, auth.LoginThrottle{})
+
+			rec := serveAsUser(tt.handler(h), tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if !gotrue.called(http.MethodPost, "/auth/v1/token") {
+				t.Error("current password was not checked")
+			}
+			updated := gotrue.called(http.MethodPut, "/auth/v1/user")
+			if want := tt.status < 300; updated != want {
+				t.Errorf("user updated = %v, want %v", updated, want)
+			}
+		})
+	}
+}
+
+func TestProfilePasswordCheckIsThrottled(t *testing.T) {
+	gotrue := newFakeGoTrue(t, "Correct-passw0rd")
+	h := NewProfileHandler(supabase.NewClient(gotrue.URL, "anon-key"), auth.Policy{}, auth.LoginThrottle{
+		Window:          time.Hour,
+		EmailLockout:    3,
+		LockoutDuration: time.Hour,
+	})
+
+	body := `{"current_password":"wrong","new_password":"Another-passw0rd"}`
+	for i := 0; i < 3; i++ {
+		if rec := serveAsUser(h.ChangePassword, body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("attempt %d: status = %d, want 400", i+1, rec.Code)
+		}
+	}
+
+	// Locked out: even the right password is not checked.
+	rec := serveAsUser(h.ChangePassword, `{"current_password":"Correct-passw0rd","new_password":"Another-passw0rd"}`)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get(echo.HeaderRetryAfter) == "" {
+		t.Error("Retry-After is not set")
+	}
+	if gotrue.called(http.MethodPut, "/auth/v1/user") {
+		t.Error("user updated while locked out")
+	}
+}
+
+// errorDetails returns the field errors of an error response.
+func errorDetails(t *testing.T, rec *httptest.ResponseRecorder) map[string]string {
+	t.Helper()
+
+	var resp ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+	return resp.Details
+}
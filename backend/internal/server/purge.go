@@ -0,0 +1,30 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// purgeTrash permanently deletes items trashed longer than the configured
+// retention, every purge interval, until ctx is done.
+func (s *Server) purgeTrash(ctx context.Context) {
+	ticker := time.NewTicker(s.config.TrashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		cutoff := time.Now().Add(-s.config.TrashRetention)
+		purged, err := s.trashRepo.PurgeTrash(ctx, cutoff)
+		if err != nil {
+			log.Printf("Trash purge failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d trashed items", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
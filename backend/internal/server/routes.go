@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 
 	custommw "github.com/{{.ProjectName}}/backend/internal/middleware"
 )
@@ -14,25 +15,88 @@ func (s *Server) bindRoutes() {
 	// Health check endpoint
 	s.echo.GET("/health", s.healthCheck)
 
+	// Per-request deadline, propagated to Supabase through the request context
+	timeout := middleware.ContextTimeout(s.config.RequestTimeout)
+
 	// Auth routes (public)
-	auth := s.echo.Group("/auth")
+	auth := s.echo.Group("/auth", timeout)
 	auth.POST("/register", s.authHandler.Register)
 	auth.POST("/login", s.authHandler.Login)
 	auth.POST("/refresh", s.authHandler.Refresh)
 	auth.POST("/logout", s.authHandler.Logout)
+	auth.POST("/otp", s.authHandler.SendOTP)
+	auth.POST("/verify", s.authHandler.VerifyOTP)
+	auth.POST("/oauth/callback", s.authHandler.OAuthCallback)
+	auth.POST("/oauth/:provider", s.authHandler.OAuthStart)
+	auth.POST("/recover", s.authHandler.Recover)
+	auth.POST("/reset-password", s.authHandler.ResetPassword)
 
 	// API v1 group (protected routes with JWT auth)
-	api := s.echo.Group("/api/v1", custommw.JWTAuth(s.jwtConfig))
+	api := s.echo.Group("/api/v1", timeout, custommw.JWTAuth(s.jwtConfig), custommw.Idempotency(s.idempotency))
 
 	// Item routes
 	api.GET("/items", s.itemHandler.ListItems)
+	api.GET("/items/trash", s.itemHandler.ListTrash)
 	api.GET("/items/:id", s.itemHandler.GetItem)
 	api.POST("/items", s.itemHandler.CreateItem)
+	api.POST("/items/batch", s.itemHandler.BatchItems)
 	api.PATCH("/items/:id", s.itemHandler.UpdateItem)
 	api.DELETE("/items/:id", s.itemHandler.DeleteItem)
+	api.POST("/items/:id/restore", s.itemHandler.RestoreItem)
+
+	// Offline sync routes
+	api.GET("/sync", s.syncHandler.Sync)
+	api.POST("/sync/push", s.syncHandler.Push)
+
+	// Profile routes
+	api.GET("/me", s.profileHandler.GetProfile)
+	api.PATCH("/me", s.profileHandler.UpdateProfile)
+	api.POST("/me/email", s.profileHandler.ChangeEmail)
+	api.POST("/me/password", s.profileHandler.ChangePassword)
+
+	// Session routes
+	api.GET("/me/sessions", s.sessionHandler.ListSessions)
+	api.DELETE("/me/sessions", s.sessionHandler.RevokeOtherSessions)
+	api.DELETE("/me/sessions/:id", s.sessionHandler.RevokeSession)
+
+	// MFA routes
+	api.GET("/mfa/factors", s.mfaHandler.ListFactors)
+	api.POST("/mfa/factors", s.mfaHandler.EnrollFactor)
+	api.POST("/mfa/factors/:id/challenge", s.mfaHandler.ChallengeFactor)
+	api.POST("/mfa/factors/:id/verify", s.mfaHandler.VerifyFactor)
+	api.DELETE("/mfa/factors/:id", s.mfaHandler.UnenrollFactor)
+
+	// Admin routes: full access for admins, read-only for support staff,
+	// optionally only from sessions that completed MFA. Requests refused
+	// for the caller's role are audited.
+	adminJWT := s.jwtConfig
+	adminJWT.RequireAAL2 = s.config.MFARequireAdmin
+	admin := s.echo.Group("/api/v1/admin", timeout,
+		custommw.JWTAuth(adminJWT),
+		custommw.Idempotency(s.idempotency),
+		auditDenied("admin.denied", custommw.RequireRole(custommw.RoleAdmin, custommw.RoleSupport)),
+		auditDenied("admin.denied", custommw.RequireRoleToWrite(custommw.RoleAdmin)),
+	)
+	s.bindAdminRoutes(admin)
 
 	// TODO: Add more protected routes here
 	// Access user in handlers with: custommw.GetUserID(c), custommw.GetUserEmail(c)
+	// Restrict routes with: custommw.RequireRole(...), custommw.RequireScope(...)
+	// Require MFA for a group by giving it its own JWTAuth with RequireAAL2 set
+}
+
+// bindAdminRoutes registers the routes under /api/v1/admin
+func (s *Server) bindAdminRoutes(admin *echo.Group) {
+	// User management needs the service role key
+	if s.adminHandler != nil {
+		admin.GET("/users", s.adminHandler.ListUsers)
+		admin.GET("/users/:id", s.adminHandler.GetUser)
+		admin.POST("/users/invite", s.adminHandler.InviteUser)
+		admin.PATCH("/users/:id", s.adminHandler.UpdateUser)
+		admin.DELETE("/users/:id", s.adminHandler.DeleteUser)
+	}
+
+	// TODO: Add more admin routes here
 }
 
 // healthCheck returns the server health status
@@ -2,59 +2,177 @@
 package server
 
 import (
+	"context"
+	"log"
+	"net"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 
 	"github.com/{{.ProjectName}}/backend/internal/auth"
 	"github.com/{{.ProjectName}}/backend/internal/config"
 	custommw "github.com/{{.ProjectName}}/backend/internal/middleware"
+	"github.com/{{.ProjectName}}/backend/internal/models"
 	"github.com/{{.ProjectName}}/backend/internal/repository"
 	"github.com/{{.ProjectName}}/backend/internal/supabase"
+	"github.com/{{.ProjectName}}/backend/internal/validation"
 )
 
 // Server wraps the Echo instance and configuration
 type Server struct {
-	echo        *echo.Echo
-	config      *config.Config
-	supabase    *supabase.Client
-	authHandler *auth.Handler
-	itemHandler *ItemHandler
-	jwtConfig   custommw.JWTConfig
+	echo           *echo.Echo
+	config         *config.Config
+	supabase       *supabase.Client
+	authHandler    *auth.Handler
+	itemHandler    *ItemHandler
+	syncHandler    *SyncHandler
+	mfaHandler     *MFAHandler
+	profileHandler *ProfileHandler
+	sessionHandler *SessionHandler
+	// adminHandler and trashRepo are nil when no service role key is
+	// configured.
+	adminHandler *AdminHandler
+	trashRepo    *repository.ItemRepository
+	jwtConfig    custommw.JWTConfig
+	idempotency  custommw.IdempotencyConfig
 }
 
 // New creates a new server instance with middleware configured
 func New(cfg *config.Config) *Server {
 	e := echo.New()
 
+	// Struct tag validation for c.Validate; the errors it returns are
+	// rendered as field errors
+	e.Validator = validation.New()
+	e.HTTPErrorHandler = httpErrorHandler(e)
+	e.IPExtractor = ipExtractor(cfg.TrustedProxies)
+
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 
-	// Initialize Supabase client
-	supabaseClient := supabase.NewClient(cfg.SupabaseURL, cfg.SupabaseKey)
+	// Initialize Supabase client with retries and circuit breaking
+	retry := supabase.RetryPolicy{
+		MaxRetries: cfg.SupabaseMaxRetries,
+		BaseDelay:  cfg.SupabaseRetryBaseDelay,
+		MaxDelay:   cfg.SupabaseRetryMaxDelay,
+	}
+	supabaseOpts := []supabase.Option{supabase.WithRetry(retry)}
+	if cfg.SupabaseBreakerThreshold > 0 {
+		supabaseOpts = append(supabaseOpts, supabase.WithCircuitBreaker(
+			supabase.NewCircuitBreaker(cfg.SupabaseBreakerThreshold, cfg.SupabaseBreakerCooldown),
+		))
+	}
+	supabaseClient := supabase.NewClient(cfg.SupabaseURL, cfg.SupabaseKey, supabaseOpts...)
+
+	policy := auth.Policy{
+		MinPasswordLength:     cfg.AuthPasswordMinLength,
+		RequiredClasses:       cfg.AuthPasswordRequiredClasses,
+		RejectBreached:        cfg.AuthPasswordRejectBreached,
+		AllowedEmailDomains:   cfg.AuthEmailAllowedDomains,
+		DeniedEmailDomains:    cfg.AuthEmailDeniedDomains,
+		BlockDisposableEmails: cfg.AuthEmailBlockDisposable,
+		InviteOnly:            cfg.AuthInviteOnly,
+		InviteCodes:           cfg.AuthInviteCodes,
+	}
+
+	// Registry of signed-in devices shared by the auth and session
+	// handlers. Access tokens of signed out sessions stay on the revocation
+	// list until they expire.
+	sessions := auth.NewMemorySessionStore()
+	revocations := custommw.NewMemoryRevocationStore(cfg.TokenRevocationMaxEntries)
+
+	// Failed sign-ins and current password checks share one throttle
+	loginThrottle := auth.LoginThrottle{
+		Store:           auth.NewMemoryAttemptStore(),
+		Window:          cfg.AuthLoginWindow,
+		DelayAfter:      cfg.AuthLoginDelayAfter,
+		BaseDelay:       cfg.AuthLoginDelayBase,
+		MaxDelay:        cfg.AuthLoginDelayMax,
+		EmailLockout:    cfg.AuthLoginEmailLockout,
+		IPLockout:       cfg.AuthLoginIPLockout,
+		LockoutDuration: cfg.AuthLoginLockoutDuration,
+	}
+
+	// JWT configuration for protected routes
+	jwtConfig := custommw.JWTConfig{
+		JWTSecret:      cfg.SupabaseJWTSecret,
+		Issuers:        cfg.JWTIssuers,
+		Audiences:      cfg.JWTAudiences,
+		AllowedRoles:   cfg.JWTAllowedRoles,
+		Leeway:         cfg.JWTLeeway,
+		RequiredClaims: cfg.JWTRequiredClaims,
+		Revocations:    revocations,
+	}
+	if cfg.SupabaseJWKSURL != "" || cfg.SupabaseJWKSFile != "" {
+		jwtConfig.JWKS = custommw.NewJWKS(cfg.SupabaseJWKSURL, cfg.SupabaseJWKSFile)
+	}
 
 	// Initialize auth handler
-	authHandler := auth.NewHandler(supabaseClient)
+	authHandler := auth.NewHandler(supabaseClient, auth.Config{
+		RecoveryRedirectURL:  cfg.AuthRecoveryRedirectURL,
+		AllowedRedirectURLs:  cfg.AuthAllowedRedirectURLs,
+		MagicLinkRedirectURL: cfg.AuthMagicLinkRedirectURL,
+		OTPCreateUser:        cfg.AuthOTPCreateUser,
+		OAuthProviders:       cfg.AuthOAuthProviders,
+		OAuthRedirectURL:     cfg.AuthOAuthRedirectURL,
+		Sessions:             sessions,
+		Revocations:          revocations,
+		RevocationTTL:        cfg.TokenRevocationTTL,
+		VerifyToken:          jwtConfig.VerifyToken,
+		LoginThrottle:        loginThrottle,
+		Policy:               policy,
+	})
 
 	// Initialize repositories
 	itemRepo := repository.NewItemRepository(supabaseClient)
 
-	// Initialize item handler
+	// Initialize item handlers
 	itemHandler := NewItemHandler(itemRepo)
+	syncHandler := NewSyncHandler(itemRepo, models.ConflictPolicy(cfg.SyncConflictPolicy),
+		syncOverlap(cfg.RequestTimeout, retry))
 
-	// JWT configuration for protected routes
-	jwtConfig := custommw.JWTConfig{
-		JWTSecret: cfg.SupabaseJWTSecret,
+	mfaHandler := NewMFAHandler(supabaseClient, cfg.MFAIssuer, sessions)
+	profileHandler := NewProfileHandler(supabaseClient, policy, loginThrottle)
+	sessionHandler := NewSessionHandler(supabaseClient, sessions, revocations, cfg.TokenRevocationTTL)
+
+	// Initialize admin handler and trash purging with a service role client
+	var adminClient *supabase.Client
+	var adminHandler *AdminHandler
+	var trashRepo *repository.ItemRepository
+	if cfg.SupabaseServiceKey != "" {
+		adminClient = supabase.NewClient(cfg.SupabaseURL, cfg.SupabaseServiceKey, supabaseOpts...)
+		adminHandler = NewAdminHandler(adminClient, revocations, cfg.TokenRevocationTTL)
+		trashRepo = repository.NewItemRepository(adminClient)
+	}
+
+	// Idempotency-Key handling for mutating routes. The shared store holds
+	// every user's responses, so only the service role may access it;
+	// config.Load requires its key for it.
+	var idempotencyStore custommw.IdempotencyStore = custommw.NewMemoryIdempotencyStore()
+	if cfg.IdempotencyStore == "postgrest" {
+		idempotencyStore = custommw.NewPostgrestIdempotencyStore(adminClient)
+	}
+	idempotency := custommw.IdempotencyConfig{
+		Store: idempotencyStore,
+		TTL:   cfg.IdempotencyTTL,
 	}
 
 	return &Server{
-		echo:        e,
-		config:      cfg,
-		supabase:    supabaseClient,
-		authHandler: authHandler,
-		itemHandler: itemHandler,
-		jwtConfig:   jwtConfig,
+		echo:           e,
+		config:         cfg,
+		supabase:       supabaseClient,
+		trashRepo:      trashRepo,
+		authHandler:    authHandler,
+		itemHandler:    itemHandler,
+		syncHandler:    syncHandler,
+		mfaHandler:     mfaHandler,
+		profileHandler: profileHandler,
+		sessionHandler: sessionHandler,
+		adminHandler:   adminHandler,
+		jwtConfig:      jwtConfig,
+		idempotency:    idempotency,
 	}
 }
 
@@ -63,6 +181,36 @@ func (s *Server) Start() error {
 	// Bind all routes
 	s.bindRoutes()
 
+	// Purge items trashed longer than the retention period
+	if s.config.TrashRetention > 0 {
+		if s.trashRepo == nil {
+			log.Printf("Trash purge disabled: SUPABASE_SERVICE_ROLE_KEY is not set")
+		} else {
+			go s.purgeTrash(context.Background())
+		}
+	}
+
 	// Start server
 	return s.echo.Start(":" + s.config.Port)
 }
+
+// ipExtractor returns how c.RealIP determines the client IP. Without trusted
+// proxies it is the connection address, so clients cannot pick their own IP
+// (and evade per-IP limits) with X-Forwarded-For or X-Real-IP. Otherwise
+// X-Forwarded-For is read up to the first address that is not a trusted
+// proxy.
+func ipExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, ipNet := range trustedProxies {
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/{{.ProjectName}}/backend/internal/auth"
+	custommw "github.com/{{.ProjectName}}/backend/internal/middleware"
+	"github.com/{{.ProjectName}}/backend/internal/models"
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+)
+
+// SessionHandler lists and revokes the current user's sessions.
+type SessionHandler struct {
+	client        *supabase.Client
+	sessions      auth.SessionStore
+	revocations   custommw.RevocationStore
+	revocationTTL time.Duration
+}
+
+// NewSessionHandler creates a new session handler over the registry the
+// auth handler records sessions in. Revoked sessions are added to
+// revocations, if set, for revocationTTL.
+func NewSessionHandler(client *supabase.Client, sessions auth.SessionStore, revocations custommw.RevocationStore, revocationTTL time.Duration) *SessionHandler {
+	return &SessionHandler{
+		client:        client,
+		sessions:      sessions,
+		revocations:   revocations,
+		revocationTTL: revocationTTL,
+	}
+}
+
+// revokeTokens rejects the access tokens of sessionID until they expire.
+func (h *SessionHandler) revokeTokens(c echo.Context, sessionID string) error {
+	if h.revocations == nil {
+		return nil
+	}
+	until := time.Now().Add(h.revocationTTL)
+	return h.revocations.Revoke(c.Request().Context(), custommw.SessionRevocationKey(sessionID), until)
+}
+
+// ListSessions returns the devices the user is signed in on.
+// GET /api/v1/me/sessions
+func (h *SessionHandler) ListSessions(c echo.Context) error {
+	userID := custommw.GetUserID(c)
+	if userID == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	sessions, err := h.sessions.List(c.Request().Context(), userID)
+	if err != nil {
+		return InternalError(c, "Failed to fetch sessions")
+	}
+
+	current := custommw.GetSessionID(c)
+	response := models.SessionListResponse{
+		Sessions: make([]models.SessionResponse, len(sessions)),
+	}
+	for i, s := range sessions {
+		response.Sessions[i] = models.SessionResponse{
+			ID:         s.ID,
+			DeviceName: s.DeviceName,
+			Platform:   s.Platform,
+			AppVersion: s.AppVersion,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			Current:    s.ID == current,
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// RevokeSession signs a device out. Its access tokens are rejected right
+// away, and its refresh token at its next refresh.
+// DELETE /api/v1/me/sessions/:id
+func (h *SessionHandler) RevokeSession(c echo.Context) error {
+	userID := custommw.GetUserID(c)
+	if userID == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	if id == custommw.GetSessionID(c) {
+		if err := h.client.SignOutScope(ctx, getToken(c), supabase.LogoutLocal); err != nil {
+			return profileError(c, err, "Failed to sign out")
+		}
+	}
+
+	found, err := h.sessions.Revoke(ctx, userID, id)
+	if err != nil {
+		return InternalError(c, "Failed to revoke session")
+	}
+	if !found {
+		return NotFound(c, "Session not found")
+	}
+	if err := h.revokeTokens(c, id); err != nil {
+		return InternalError(c, "Failed to revoke session")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RevokeOtherSessions signs out every device but the current one.
+// DELETE /api/v1/me/sessions
+func (h *SessionHandler) RevokeOtherSessions(c echo.Context) error {
+	userID := custommw.GetUserID(c)
+	if userID == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	ctx := c.Request().Context()
+	if err := h.client.SignOutScope(ctx, getToken(c), supabase.LogoutOthers); err != nil {
+		return profileError(c, err, "Failed to sign out other sessions")
+	}
+
+	current := custommw.GetSessionID(c)
+	sessions, err := h.sessions.List(ctx, userID)
+	if err != nil {
+		return InternalError(c, "Failed to revoke sessions")
+	}
+	for _, s := range sessions {
+		if s.ID == current {
+			continue
+		}
+		if err := h.revokeTokens(c, s.ID); err != nil {
+			return InternalError(c, "Failed to revoke sessions")
+		}
+	}
+	if err := h.sessions.RevokeOthers(ctx, userID, current); err != nil {
+		return InternalError(c, "Failed to revoke sessions")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,422 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	custommw "github.com/{{.ProjectName}}/backend/internal/middleware"
+	"github.com/{{.ProjectName}}/backend/internal/models"
+	"github.com/{{.ProjectName}}/backend/internal/repository"
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+)
+
+// syncOverlap returns the overlap of sync tokens for writes that run for at
+// most requestTimeout, retried with retry. The request timeout already
+// bounds retries; their delays are added anyway, in case it is raised past
+// them, along with a margin for commit lag and clock differences between
+// instances.
+func syncOverlap(requestTimeout time.Duration, retry supabase.RetryPolicy) time.Duration {
+	return requestTimeout + time.Duration(retry.MaxRetries)*retry.MaxDelay + 5*time.Second
+}
+
+// syncPageSize is the most items, and the most deletions, a sync response
+// holds. Larger changes are returned over several requests.
+const syncPageSize = 500
+
+// errInvalidSyncToken is returned for tokens not issued by this server.
+var errInvalidSyncToken = errors.New("invalid sync token")
+
+// syncToken is the decoded form of the opaque token handed to clients.
+type syncToken struct {
+	Since time.Time `json:"t"`
+
+	// Set on the tokens of partial responses: Next is the token to issue
+	// once the sync completes, and Items and Deleted the position reached.
+	// Items are returned before deletions, so a Deleted cursor means every
+	// item was returned.
+	Next    *time.Time         `json:"n,omitempty"`
+	Items   *repository.Cursor `json:"i,omitempty"`
+	Deleted *repository.Cursor `json:"d,omitempty"`
+}
+
+// encode returns the opaque, URL-safe form of the token.
+func (t syncToken) encode() string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeSyncToken parses a token produced by syncToken.encode.
+func decodeSyncToken(s string) (syncToken, error) {
+	var t syncToken
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return t, errInvalidSyncToken
+	}
+	if err := json.Unmarshal(data, &t); err != nil || t.Since.IsZero() && t.Next == nil {
+		return t, errInvalidSyncToken
+	}
+
+	return t, nil
+}
+
+// SyncHandler handles offline sync requests.
+type SyncHandler struct {
+	repo    *repository.ItemRepository
+	policy  models.ConflictPolicy
+	overlap time.Duration
+}
+
+// NewSyncHandler creates a new sync handler. policy is the conflict policy
+// used when a push does not ask for one. overlap is subtracted from the
+// sync time when issuing a token, so that writes still in flight during a
+// sync are picked up by the next one: item timestamps are taken before
+// they are written, so it must be at least as long as a write can take.
+// Clients may therefore see an unchanged item twice and must upsert by ID.
+func NewSyncHandler(repo *repository.ItemRepository, policy models.ConflictPolicy, overlap time.Duration) *SyncHandler {
+	return &SyncHandler{repo: repo, policy: policy, overlap: overlap}
+}
+
+// Sync returns the items changed and deleted since a sync token. Without a
+// token it returns every item, for the client's initial download. When
+// has_more is set the response is partial, and the client must sync again
+// right away with the returned token.
+// GET /api/v1/sync?since=<token>
+func (h *SyncHandler) Sync(c echo.Context) error {
+	userID := custommw.GetUserID(c)
+	if userID == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	var token syncToken
+	if raw := c.QueryParam("since"); raw != "" {
+		t, err := decodeSyncToken(raw)
+		if err != nil {
+			return ValidationError(c, map[string]string{"since": "Sync token is invalid"})
+		}
+		token = t
+	}
+	since := token.Since
+
+	// Taken before reading so nothing written during the sync is skipped;
+	// partial responses carry it over to the last one.
+	next := time.Now().UTC().Add(-h.overlap)
+	if token.Next != nil {
+		next = *token.Next
+	}
+	more := syncToken{Since: since, Next: &next}
+
+	ctx := c.Request().Context()
+	accessToken := getToken(c)
+
+	var items []models.Item
+	if token.Deleted == nil {
+		page, err := h.repo.ChangedSince(ctx, userID, since, token.Items, syncPageSize, accessToken)
+		if err == repository.ErrInvalidCursor {
+			return ValidationError(c, map[string]string{"since": "Sync token is invalid"})
+		}
+		if err != nil {
+			return itemError(c, err, "Failed to fetch changes")
+		}
+		items = page.Items
+		more.Items = page.Next
+	}
+
+	var tombstones []models.Tombstone
+	if more.Items == nil && !since.IsZero() {
+		page, err := h.repo.TombstonesSince(ctx, userID, since, token.Deleted, syncPageSize, accessToken)
+		if err == repository.ErrInvalidCursor {
+			return ValidationError(c, map[string]string{"since": "Sync token is invalid"})
+		}
+		if err != nil {
+			return itemError(c, err, "Failed to fetch deletions")
+		}
+		tombstones = page.Tombstones
+		more.Deleted = page.Next
+	}
+
+	response := models.SyncResponse{
+		Items:     make([]models.ItemResponse, 0, len(items)),
+		Deleted:   make([]models.TombstoneResponse, 0, len(tombstones)),
+		SyncToken: syncToken{Since: next}.encode(),
+	}
+	if more.Items != nil || more.Deleted != nil {
+		response.SyncToken = more.encode()
+		response.HasMore = true
+	}
+	// Trashed items are gone as far as the client's list is concerned.
+	for _, item := range items {
+		if item.IsTrashed() {
+			response.Deleted = append(response.Deleted, models.TombstoneResponse{
+				ID:        item.ID,
+				DeletedAt: *item.DeletedAt,
+			})
+			continue
+		}
+		response.Items = append(response.Items, item.ToResponse())
+	}
+	for _, tombstone := range tombstones {
+		response.Deleted = append(response.Deleted, tombstone.ToResponse())
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// Push applies an ordered batch of offline mutations and reports the outcome
+// of each one. Mutations are applied one by one; a failed mutation does not
+// stop the ones after it, but an invalid one rejects the whole push.
+// POST /api/v1/sync/push
+func (h *SyncHandler) Push(c echo.Context) error {
+	userID := custommw.GetUserID(c)
+	if userID == "" {
+		return Unauthorized(c, "User not authenticated")
+	}
+
+	var req models.PushRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest(c, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+	if req.Policy == "" {
+		req.Policy = h.policy
+	}
+
+	p := &pushRun{
+		handler: h,
+		ctx:     c.Request().Context(),
+		userID:  userID,
+		token:   getToken(c),
+		policy:  req.Policy,
+		created: make(map[string]string),
+	}
+
+	response := models.PushResponse{
+		Results: make([]models.MutationResult, len(req.Mutations)),
+	}
+	for i, m := range req.Mutations {
+		response.Results[i] = p.apply(m)
+		response.Results[i].ClientID = m.ClientID
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// pushRun holds the state of one push while its mutations are applied.
+type pushRun struct {
+	handler *SyncHandler
+	ctx     context.Context
+	userID  string
+	token   string
+	policy  models.ConflictPolicy
+	// created maps the client IDs of applied creates to server item IDs.
+	created map[string]string
+}
+
+// apply applies a single mutation, validated by its struct tags.
+func (p *pushRun) apply(m models.Mutation) models.MutationResult {
+	if m.Op == models.MutationCreate {
+		return p.create(m)
+	}
+
+	if id, ok := p.created[m.ID]; ok {
+		m.ID = id
+	}
+	if m.Op == models.MutationUpdate {
+		return p.update(m)
+	}
+	return p.delete(m)
+}
+
+func (p *pushRun) create(m models.Mutation) models.MutationResult {
+	item, err := p.handler.repo.Create(p.ctx, p.userID, *m.Create, p.token)
+	if err != nil || item == nil {
+		return failedMutation(err)
+	}
+
+	p.created[m.ClientID] = item.ID
+	return appliedMutation(models.MutationApplied, item)
+}
+
+func (p *pushRun) update(m models.Mutation) models.MutationResult {
+	current, result, ok := p.current(m.ID)
+	if !ok {
+		return result
+	}
+
+	changes := *m.Changes
+	status := models.MutationApplied
+	var conflicting []string
+
+	if isConflict(m, current) {
+		switch p.policy {
+		case models.ConflictServerWins:
+			return conflictMutation(current, changedFields(changes))
+		case models.ConflictMerge:
+			changes, conflicting = mergeChanges(changes, m.Base, current)
+			if len(changedFields(changes)) == 0 {
+				// Every change lost to the server; there is nothing to write.
+				return conflictMutation(current, conflicting)
+			}
+			status = models.MutationMerged
+		}
+	}
+
+	item, err := p.handler.repo.Update(p.ctx, m.ID, changes, p.ifUnchanged(current), p.token)
+	if err == repository.ErrVersionMismatch {
+		return p.raced(m.ID, changedFields(changes))
+	}
+	if err != nil || item == nil {
+		return failedMutation(err)
+	}
+
+	result = appliedMutation(status, item)
+	result.ConflictingFields = conflicting
+	return result
+}
+
+func (p *pushRun) delete(m models.Mutation) models.MutationResult {
+	current, result, ok := p.current(m.ID)
+	if !ok {
+		return result
+	}
+
+	if isConflict(m, current) && p.policy == models.ConflictServerWins {
+		return conflictMutation(current, nil)
+	}
+
+	// Offline deletes move the item to the trash, like DELETE /items/:id.
+	_, err := p.handler.repo.Trash(p.ctx, m.ID, p.ifUnchanged(current), p.token)
+	if err == repository.ErrVersionMismatch {
+		return p.raced(m.ID, nil)
+	}
+	if err != nil {
+		return failedMutation(err)
+	}
+
+	return models.MutationResult{Status: models.MutationApplied}
+}
+
+// current loads the item a mutation targets, or the result to report if
+// it cannot be changed.
+func (p *pushRun) current(id string) (*models.Item, models.MutationResult, bool) {
+	item, err := p.handler.repo.GetByID(p.ctx, id, p.token)
+	if supabase.IsNotFound(err) || (err == nil && (item.UserID != p.userID || item.IsTrashed())) {
+		return nil, models.MutationResult{Status: models.MutationNotFound, Error: "Item not found"}, false
+	}
+	if err != nil {
+		return nil, failedMutation(err), false
+	}
+	return item, models.MutationResult{}, true
+}
+
+// ifUnchanged returns the item a write must be conditioned on, so that a
+// concurrent change between reading and writing is not overwritten. Only
+// client_wins writes unconditionally.
+func (p *pushRun) ifUnchanged(current *models.Item) *models.Item {
+	if p.policy == models.ConflictClientWins {
+		return nil
+	}
+	return current
+}
+
+// raced reports a conflict for an item that changed while it was being
+// written, with its latest server state.
+func (p *pushRun) raced(id string, fields []string) models.MutationResult {
+	current, result, ok := p.current(id)
+	if !ok {
+		return result
+	}
+	return conflictMutation(current, fields)
+}
+
+// isConflict reports whether the item changed since the mutation's base
+// version. Mutations without a base version never conflict.
+func isConflict(m models.Mutation, current *models.Item) bool {
+	return m.BaseVersion != nil && !m.BaseVersion.Equal(current.Version())
+}
+
+// mergeChanges keeps each changed field the server has not touched since
+// the client's base values, and drops the others in favour of the server
+// value, returning their names.
+func mergeChanges(changes models.UpdateItemRequest, base *models.UpdateItemRequest, current *models.Item) (models.UpdateItemRequest, []string) {
+	if base == nil {
+		base = &models.UpdateItemRequest{}
+	}
+
+	var merged models.UpdateItemRequest
+	var conflicting []string
+
+	if changes.Title != nil {
+		if (base.Title != nil && *base.Title == current.Title) || *changes.Title == current.Title {
+			merged.Title = changes.Title
+		} else {
+			conflicting = append(conflicting, "title")
+		}
+	}
+	if changes.Description != nil {
+		serverValue := ""
+		if current.Description != nil {
+			serverValue = *current.Description
+		}
+		if (base.Description != nil && *base.Description == serverValue) || *changes.Description == serverValue {
+			merged.Description = changes.Description
+		} else {
+			conflicting = append(conflicting, "description")
+		}
+	}
+	if changes.Completed != nil {
+		if (base.Completed != nil && *base.Completed == current.Completed) || *changes.Completed == current.Completed {
+			merged.Completed = changes.Completed
+		} else {
+			conflicting = append(conflicting, "completed")
+		}
+	}
+
+	return merged, conflicting
+}
+
+// changedFields lists the fields set in changes.
+func changedFields(changes models.UpdateItemRequest) []string {
+	var fields []string
+	if changes.Title != nil {
+		fields = append(fields, "title")
+	}
+	if changes.Description != nil {
+		fields = append(fields, "description")
+	}
+	if changes.Completed != nil {
+		fields = append(fields, "completed")
+	}
+	return fields
+}
+
+func appliedMutation(status models.MutationStatus, item *models.Item) models.MutationResult {
+	resp := item.ToResponse()
+	return models.MutationResult{Status: status, Item: &resp}
+}
+
+func conflictMutation(current *models.Item, fields []string) models.MutationResult {
+	resp := current.ToResponse()
+	return models.MutationResult{
+		Status:            models.MutationConflict,
+		Item:              &resp,
+		ConflictingFields: fields,
+		Error:             "Item changed on the server",
+	}
+}
+
+func failedMutation(err error) models.MutationResult {
+	if supabase.IsRLSDenied(err) {
+		return models.MutationResult{Status: models.MutationFailed, Error: "Access denied"}
+	}
+	return models.MutationResult{Status: models.MutationFailed, Error: "Failed to apply mutation"}
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/{{.ProjectName}}/backend/internal/models"
+	"github.com/{{.ProjectName}}/backend/internal/repository"
+	"github.com/{{.ProjectName}}/backend/internal/supabase"
+	"github.com/{{.ProjectName}}/backend/internal/validation"
+)
+
+func TestPushRejectsInvalidMutations(t *testing.T) {
+	const id = "5f0c2b7e-8d0a-4c55-9a43-0d6f3a1b2c3d"
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "blank title in update", body: `{"mutations":[{"client_id":"m1","op":"update","id":"` + id + `","changes":{"title":"   "}}]}`},
+		{name: "blank title in create", body: `{"mutations":[{"client_id":"m1","op":"create","create":{"title":"   "}}]}`},
+		{name: "update without changes", body: `{"mutations":[{"client_id":"m1","op":"update","id":"` + id + `"}]}`},
+		{name: "create without item", body: `{"mutations":[{"client_id":"m1","op":"create"}]}`},
+		{name: "malformed id", body: `{"mutations":[{"client_id":"m1","op":"delete","id":"42"}]}`},
+		{name: "missing client_id", body: `{"mutations":[{"op":"delete","id":"` + id + `"}]}`},
+		{name: "unknown op", body: `{"mutations":[{"client_id":"m1","op":"upsert","id":"` + id + `"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			supabaseServer := newFakeGoTrue(t, "")
+			repo := repository.NewItemRepository(supabase.NewClient(supabaseServer.URL, "anon-key"))
+			h := NewSyncHandler(repo, models.ConflictServerWins, 0)
+
+			rec := serveAsUser(h.Push, tt.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body.String())
+			}
+			if len(supabaseServer.requests) != 0 {
+				t.Errorf("Supabase received %v, want no requests", supabaseServer.requests)
+			}
+		})
+	}
+}
+
+func TestPushAcceptsValidMutations(t *testing.T) {
+	body := `{"mutations":[
+		{"client_id":"0b7c6a3e-1f2d-4e5a-8b9c-0d1e2f3a4b5c","op":"create","create":{"title":"Milk"}},
+		{"client_id":"m2","op":"update","id":"0b7c6a3e-1f2d-4e5a-8b9c-0d1e2f3a4b5c","changes":{"completed":true},"base":{"title":""}},
+		{"client_id":"m3","op":"delete","id":"0b7c6a3e-1f2d-4e5a-8b9c-0d1e2f3a4b5c"}
+	]}`
+
+	var req models.PushRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatal(err)
+	}
+	if err := validation.New().Validate(&req); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestSyncPagesLargeChanges(t *testing.T) {
+	var queries []string
+	postgrest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Path+"?"+r.URL.RawQuery)
+
+		rows := []map[string]interface{}{}
+		if r.URL.Path == "/rest/v1/items" && len(queries) == 1 {
+			created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			for i := 0; i <= syncPageSize; i++ {
+				rows = append(rows, map[string]interface{}{
+					"id":         fmt.Sprintf("00000000-0000-0000-0000-%012d", i),
+					"title":      "Item",
+					"created_at": created.Add(time.Duration(i) * time.Second),
+				})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rows)
+	}))
+	t.Cleanup(postgrest.Close)
+
+	repo := repository.NewItemRepository(supabase.NewClient(postgrest.URL, "anon-key"))
+	h := NewSyncHandler(repo, models.ConflictServerWins, time.Minute)
+	since := syncToken{Since: time.Now().Add(-time.Hour)}.encode()
+
+	sync := func(token string) models.SyncResponse {
+		t.Helper()
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/?since="+token, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set("user_id", "user-1")
+		if err := h.Sync(c); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp models.SyncResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	first := sync(since)
+	if len(first.Items) != syncPageSize || !first.HasMore {
+		t.Fatalf("first page has %d items, has_more = %v; want %d and true", len(first.Items), first.HasMore, syncPageSize)
+	}
+	if len(queries) != 1 {
+		t.Errorf("deletions fetched before every item was returned: %v", queries)
+	}
+
+	second := sync(first.SyncToken)
+	if len(second.Items) != 0 || second.HasMore {
+		t.Fatalf("second page has %d items, has_more = %v; want none and false", len(second.Items), second.HasMore)
+	}
+	if !strings.Contains(queries[1], "id.gt.00000000-0000-0000-0000-000000000499") {
+		t.Errorf("second page query %q does not continue after the first", queries[1])
+	}
+	if !strings.HasPrefix(queries[2], "/rest/v1/item_tombstones?") {
+		t.Errorf("deletions query = %q", queries[2])
+	}
+
+	// The sync completes with a token from before it started.
+	token, err := decodeSyncToken(second.SyncToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Next != nil || token.Items != nil || token.Deleted != nil || time.Since(token.Since) < time.Minute {
+		t.Errorf("final token = %+v, want a plain token from a minute before the first page", token)
+	}
+}
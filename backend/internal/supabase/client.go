@@ -3,10 +3,12 @@ package supabase
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -15,6 +17,8 @@ type Client struct {
 	baseURL    string
 	apiKey     string
 	httpClient *http.Client
+	retry      RetryPolicy
+	breaker    *CircuitBreaker
 }
 
 // AuthResponse represents Supabase auth response.
@@ -28,9 +32,19 @@ type AuthResponse struct {
 
 // User represents a Supabase user.
 type User struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	ID               string                 `json:"id"`
+	Email            string                 `json:"email"`
+	Phone            string                 `json:"phone,omitempty"`
+	Role             string                 `json:"role,omitempty"`
+	AppMetadata      map[string]interface{} `json:"app_metadata,omitempty"`
+	UserMetadata     map[string]interface{} `json:"user_metadata,omitempty"`
+	EmailConfirmedAt *time.Time             `json:"email_confirmed_at,omitempty"`
+	InvitedAt        *time.Time             `json:"invited_at,omitempty"`
+	LastSignInAt     *time.Time             `json:"last_sign_in_at,omitempty"`
+	BannedUntil      *time.Time             `json:"banned_until,omitempty"`
+	Factors          []Factor               `json:"factors,omitempty"`
+	CreatedAt        time.Time              `json:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at"`
 }
 
 // AuthError represents a Supabase auth error.
@@ -40,48 +54,74 @@ type AuthError struct {
 }
 
 // NewClient creates a new Supabase client.
-func NewClient(url, key string) *Client {
-	return &Client{
+func NewClient(url, key string, opts ...Option) *Client {
+	c := &Client{
 		baseURL: url,
 		apiKey:  key,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 // SignUp registers a new user with email and password.
 func (c *Client) SignUp(email, password string) (*AuthResponse, error) {
+	return c.SignUpContext(context.Background(), email, password)
+}
+
+// SignUpContext registers a new user, aborting if ctx is cancelled.
+func (c *Client) SignUpContext(ctx context.Context, email, password string) (*AuthResponse, error) {
 	payload := map[string]string{
 		"email":    email,
 		"password": password,
 	}
 
-	return c.authRequest("/auth/v1/signup", payload)
+	return c.authRequest(ctx, "/auth/v1/signup", payload)
 }
 
 // SignIn authenticates a user with email and password.
 func (c *Client) SignIn(email, password string) (*AuthResponse, error) {
+	return c.SignInContext(context.Background(), email, password)
+}
+
+// SignInContext authenticates a user, aborting if ctx is cancelled.
+func (c *Client) SignInContext(ctx context.Context, email, password string) (*AuthResponse, error) {
 	payload := map[string]string{
 		"email":    email,
 		"password": password,
 	}
 
-	return c.authRequest("/auth/v1/token?grant_type=password", payload)
+	return c.authRequest(ctx, "/auth/v1/token?grant_type=password", payload)
 }
 
 // RefreshToken refreshes an access token using a refresh token.
 func (c *Client) RefreshToken(refreshToken string) (*AuthResponse, error) {
+	return c.RefreshTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshTokenContext refreshes an access token, aborting if ctx is cancelled.
+func (c *Client) RefreshTokenContext(ctx context.Context, refreshToken string) (*AuthResponse, error) {
 	payload := map[string]string{
 		"refresh_token": refreshToken,
 	}
 
-	return c.authRequest("/auth/v1/token?grant_type=refresh_token", payload)
+	return c.authRequest(ctx, "/auth/v1/token?grant_type=refresh_token", payload)
 }
 
 // SignOut invalidates a user's session.
 func (c *Client) SignOut(accessToken string) error {
-	req, err := http.NewRequest("POST", c.baseURL+"/auth/v1/logout", nil)
+	return c.SignOutContext(context.Background(), accessToken)
+}
+
+// SignOutContext invalidates a user's session, aborting if ctx is cancelled.
+func (c *Client) SignOutContext(ctx context.Context, accessToken string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/auth/v1/logout", nil)
 	if err != nil {
 		return err
 	}
@@ -89,7 +129,7 @@ func (c *Client) SignOut(accessToken string) error {
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("apikey", c.apiKey)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -102,13 +142,129 @@ func (c *Client) SignOut(accessToken string) error {
 	return nil
 }
 
-func (c *Client) authRequest(endpoint string, payload map[string]string) (*AuthResponse, error) {
+// UserAttributes are the fields a signed-in user can change about
+// themselves. Nil fields are left unchanged.
+type UserAttributes struct {
+	Email    *string                `json:"email,omitempty"`
+	Password *string                `json:"password,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// RecoverPassword emails a password recovery link leading to redirectTo,
+// if set. GoTrue answers the same whether or not the email is registered.
+func (c *Client) RecoverPassword(ctx context.Context, email, redirectTo string) error {
+	endpoint := "/auth/v1/recover"
+	if redirectTo != "" {
+		endpoint += "?redirect_to=" + url.QueryEscape(redirectTo)
+	}
+
+	payload := map[string]string{"email": email}
+	_, err := c.gotrue(ctx, http.MethodPost, endpoint, "", payload, nil)
+	return err
+}
+
+// OTP verification types accepted by VerifyOTP.
+const (
+	OTPTypeEmail     = "email"
+	OTPTypeMagicLink = "magiclink"
+	OTPTypeSignup    = "signup"
+	OTPTypeInvite    = "invite"
+	OTPTypeRecovery  = "recovery"
+)
+
+// VerifyOTPParams identifies a one-time password to verify: either an
+// emailed code with its email, or the token hash of an email link.
+type VerifyOTPParams struct {
+	Type      string `json:"type"`
+	Email     string `json:"email,omitempty"`
+	Token     string `json:"token,omitempty"`
+	TokenHash string `json:"token_hash,omitempty"`
+}
+
+// SignInWithOTP emails a magic link leading to redirectTo, if set, and a
+// 6-digit code to email. Unless createUser is set, unknown emails get
+// nothing and an error is returned.
+func (c *Client) SignInWithOTP(ctx context.Context, email, redirectTo string, createUser bool) error {
+	endpoint := "/auth/v1/otp"
+	if redirectTo != "" {
+		endpoint += "?redirect_to=" + url.QueryEscape(redirectTo)
+	}
+
+	payload := map[string]interface{}{
+		"email":       email,
+		"create_user": createUser,
+	}
+	_, err := c.gotrue(ctx, http.MethodPost, endpoint, "", payload, nil)
+	return err
+}
+
+// VerifyOTP exchanges a one-time password for a session.
+func (c *Client) VerifyOTP(ctx context.Context, params VerifyOTPParams) (*AuthResponse, error) {
+	var authResp AuthResponse
+	if _, err := c.gotrue(ctx, http.MethodPost, "/auth/v1/verify", "", params, &authResp); err != nil {
+		return nil, err
+	}
+	return &authResp, nil
+}
+
+// AuthorizeURL returns the URL that starts an OAuth PKCE sign-in with
+// provider in the user's browser. After signing in, the browser is sent to
+// redirectTo with a code for ExchangeCodeForSession.
+func (c *Client) AuthorizeURL(provider, redirectTo, codeChallenge string) string {
+	query := url.Values{}
+	query.Set("provider", provider)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "s256")
+	if redirectTo != "" {
+		query.Set("redirect_to", redirectTo)
+	}
+	return c.baseURL + "/auth/v1/authorize?" + query.Encode()
+}
+
+// ExchangeCodeForSession exchanges the code of an OAuth PKCE sign-in, with
+// the verifier of the challenge it was started with, for a session.
+func (c *Client) ExchangeCodeForSession(ctx context.Context, authCode, codeVerifier string) (*AuthResponse, error) {
+	payload := map[string]string{
+		"auth_code":     authCode,
+		"code_verifier": codeVerifier,
+	}
+
+	return c.authRequest(ctx, "/auth/v1/token?grant_type=pkce", payload)
+}
+
+// UpdateUser changes the user that accessToken belongs to.
+func (c *Client) UpdateUser(ctx context.Context, accessToken string, attrs UserAttributes) (*User, error) {
+	var user User
+	if _, err := c.gotrue(ctx, http.MethodPut, "/auth/v1/user", accessToken, attrs, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Logout scopes for SignOutScope.
+const (
+	// LogoutLocal ends only the session of the access token.
+	LogoutLocal = "local"
+	// LogoutOthers ends every other session of the user.
+	LogoutOthers = "others"
+	// LogoutGlobal ends every session of the user.
+	LogoutGlobal = "global"
+)
+
+// SignOutScope ends the sessions in scope for the user accessToken
+// belongs to. SignOut uses GoTrue's default scope, global.
+func (c *Client) SignOutScope(ctx context.Context, accessToken, scope string) error {
+	_, err := c.gotrue(ctx, http.MethodPost, "/auth/v1/logout?scope="+url.QueryEscape(scope), accessToken, nil, nil)
+	return err
+}
+
+func (c *Client) authRequest(ctx context.Context, endpoint string, payload map[string]string) (*AuthResponse, error) {
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", c.baseURL+endpoint, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+endpoint, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
@@ -116,7 +272,7 @@ func (c *Client) authRequest(endpoint string, payload map[string]string) (*AuthR
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("apikey", c.apiKey)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -128,11 +284,7 @@ func (c *Client) authRequest(endpoint string, payload map[string]string) (*AuthR
 	}
 
 	if resp.StatusCode >= 400 {
-		var authErr AuthError
-		if err := json.Unmarshal(respBody, &authErr); err != nil {
-			return nil, fmt.Errorf("auth error: %s", string(respBody))
-		}
-		return nil, fmt.Errorf("%s: %s", authErr.Error, authErr.Description)
+		return nil, newAuthAPIError(resp.StatusCode, respBody)
 	}
 
 	var authResp AuthResponse
@@ -0,0 +1,185 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// fakeGoTrue is an httptest stand-in for GoTrue that records the request it
+// receives and answers with a fixed response.
+type fakeGoTrue struct {
+	*httptest.Server
+	status int
+	body   string
+
+	// Of the last request.
+	method string
+	path   string
+	query  map[string][]string
+	apiKey string
+	json   map[string]interface{}
+}
+
+func newFakeGoTrue(t *testing.T, status int, body string) *fakeGoTrue {
+	t.Helper()
+
+	f := &fakeGoTrue{status: status, body: body}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.method = r.Method
+		f.path = r.URL.Path
+		f.query = r.URL.Query()
+		f.apiKey = r.Header.Get("apikey")
+		f.json = nil
+		_ = json.NewDecoder(r.Body).Decode(&f.json)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.status)
+		_, _ = w.Write([]byte(f.body))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestSignInWithOTP(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		redirectTo string
+		createUser bool
+		wantQuery  string
+		wantErr    string
+	}{
+		{name: "registered email", status: http.StatusOK, body: `{}`},
+		{name: "creates user", status: http.StatusOK, body: `{}`, createUser: true},
+		{name: "redirect", status: http.StatusOK, body: `{}`, redirectTo: "myapp://signed-in?x=1", wantQuery: "myapp://signed-in?x=1"},
+		{
+			name:    "unknown email without create_user",
+			status:  http.StatusUnprocessableEntity,
+			body:    `{"code":422,"error_code":"otp_disabled","msg":"Signups not allowed for otp"}`,
+			wantErr: "otp_disabled: Signups not allowed for otp",
+		},
+		{
+			name:    "rate limited",
+			status:  http.StatusTooManyRequests,
+			body:    `{"code":429,"error_code":"over_email_send_rate_limit","msg":"Email rate limit exceeded"}`,
+			wantErr: "over_email_send_rate_limit: Email rate limit exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotrue := newFakeGoTrue(t, tt.status, tt.body)
+			client := NewClient(gotrue.URL, "anon-key")
+
+			err := client.SignInWithOTP(context.Background(), "a@example.com", tt.redirectTo, tt.createUser)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("SignInWithOTP: %v", err)
+			}
+			if tt.wantErr != "" {
+				authErr, ok := AsAuthAPIError(err)
+				if !ok || authErr.Error() != tt.wantErr || authErr.Status != tt.status {
+					t.Fatalf("err = %v, want %d %s", err, tt.status, tt.wantErr)
+				}
+			}
+
+			if gotrue.method != http.MethodPost || gotrue.path != "/auth/v1/otp" {
+				t.Errorf("request = %s %s, want POST /auth/v1/otp", gotrue.method, gotrue.path)
+			}
+			if gotrue.apiKey != "anon-key" {
+				t.Errorf("apikey = %q", gotrue.apiKey)
+			}
+			if got := gotrue.query["redirect_to"]; tt.wantQuery == "" && got != nil || tt.wantQuery != "" && (len(got) != 1 || got[0] != tt.wantQuery) {
+				t.Errorf("redirect_to = %v, want %q", got, tt.wantQuery)
+			}
+			want := map[string]interface{}{"email": "a@example.com", "create_user": tt.createUser}
+			if !reflect.DeepEqual(gotrue.json, want) {
+				t.Errorf("body = %v, want %v", gotrue.json, want)
+			}
+		})
+	}
+}
+
+func TestVerifyOTP(t *testing.T) {
+	session := `{"access_token":"access","refresh_token":"refresh","expires_in":3600,"token_type":"bearer","user":{"id":"user-1","email":"a@example.com"}}`
+
+	tests := []struct {
+		name            string
+		params          VerifyOTPParams
+		status          int
+		body            string
+		wantBody        map[string]interface{}
+		wantErr         bool
+		wantUnavailable bool
+	}{
+		{
+			name:     "code",
+			params:   VerifyOTPParams{Type: OTPTypeEmail, Email: "a@example.com", Token: "123456"},
+			status:   http.StatusOK,
+			body:     session,
+			wantBody: map[string]interface{}{"type": "email", "email": "a@example.com", "token": "123456"},
+		},
+		{
+			name:     "token hash",
+			params:   VerifyOTPParams{Type: OTPTypeMagicLink, TokenHash: "pkce_abc"},
+			status:   http.StatusOK,
+			body:     session,
+			wantBody: map[string]interface{}{"type": "magiclink", "token_hash": "pkce_abc"},
+		},
+		{
+			name:     "expired",
+			params:   VerifyOTPParams{Type: OTPTypeEmail, Email: "a@example.com", Token: "000000"},
+			status:   http.StatusForbidden,
+			body:     `{"code":403,"error_code":"otp_expired","msg":"Token has expired or is invalid"}`,
+			wantBody: map[string]interface{}{"type": "email", "email": "a@example.com", "token": "000000"},
+			wantErr:  true,
+		},
+		{
+			name:            "unavailable",
+			params:          VerifyOTPParams{Type: OTPTypeMagicLink, TokenHash: "pkce_abc"},
+			status:          http.StatusServiceUnavailable,
+			body:            `upstream unavailable`,
+			wantBody:        map[string]interface{}{"type": "magiclink", "token_hash": "pkce_abc"},
+			wantErr:         true,
+			wantUnavailable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotrue := newFakeGoTrue(t, tt.status, tt.body)
+			client := NewClient(gotrue.URL, "anon-key")
+
+			resp, err := client.VerifyOTP(context.Background(), tt.params)
+			if gotrue.method != http.MethodPost || gotrue.path != "/auth/v1/verify" {
+				t.Errorf("request = %s %s, want POST /auth/v1/verify", gotrue.method, gotrue.path)
+			}
+			if !reflect.DeepEqual(gotrue.json, tt.wantBody) {
+				t.Errorf("body = %v, want %v", gotrue.json, tt.wantBody)
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("VerifyOTP succeeded, want error")
+				}
+				if got := IsUnavailable(err); got != tt.wantUnavailable {
+					t.Errorf("IsUnavailable(%v) = %v, want %v", err, got, tt.wantUnavailable)
+				}
+				if authErr, ok := AsAuthAPIError(err); !ok || authErr.Status != tt.status {
+					t.Errorf("err = %v, want status %d", err, tt.status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyOTP: %v", err)
+			}
+			if resp.AccessToken != "access" || resp.RefreshToken != "refresh" || resp.User.ID != "user-1" {
+				t.Errorf("session = %+v", resp)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package supabase
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -25,6 +26,7 @@ const (
 	OpILike FilterOperator = "ilike"
 	OpIn    FilterOperator = "in"
 	OpIs    FilterOperator = "is"
+	OpNotIs FilterOperator = "not.is"
 )
 
 // Filter represents a query filter.
@@ -34,14 +36,45 @@ type Filter struct {
 	Value    string
 }
 
+// Condition formats a single filter for use inside Or and And groups,
+// quoting the value when it contains PostgREST reserved characters.
+func Condition(column string, operator FilterOperator, value string) string {
+	return fmt.Sprintf("%s.%s.%s", column, operator, quoteValue(value))
+}
+
+// And groups conditions so that all of them must match.
+func And(conditions ...string) string {
+	return "and(" + strings.Join(conditions, ",") + ")"
+}
+
+// InList formats values for use with OpIn, quoting them as needed.
+func InList(values ...string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quoteValue(v)
+	}
+	return "(" + strings.Join(quoted, ",") + ")"
+}
+
+// quoteValue wraps value in double quotes if it would otherwise break
+// the PostgREST logical expression syntax.
+func quoteValue(value string) string {
+	if !strings.ContainsAny(value, ",.:()\" \\") {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
 // QueryBuilder provides a fluent API for building database queries.
 type QueryBuilder struct {
 	client    *Client
 	table     string
 	columns   []string
 	filters   []Filter
-	orderBy   string
-	orderAsc  bool
+	orGroups  []string
+	orders    []string
 	limit     int
 	offset    int
 	single    bool
@@ -80,10 +113,28 @@ func (q *QueryBuilder) Eq(column, value string) *QueryBuilder {
 	return q.Filter(column, OpEq, value)
 }
 
-// Order specifies the column to order by.
+// Or adds a group of conditions of which at least one must match.
+// Conditions are built with Condition and And.
+func (q *QueryBuilder) Or(conditions ...string) *QueryBuilder {
+	q.orGroups = append(q.orGroups, "("+strings.Join(conditions, ",")+")")
+	return q
+}
+
+// Order adds a column to order by. Calling it again adds a tie-breaker.
 func (q *QueryBuilder) Order(column string, ascending bool) *QueryBuilder {
-	q.orderBy = column
-	q.orderAsc = ascending
+	order := column
+	if !ascending {
+		order += ".desc"
+	}
+	q.orders = append(q.orders, order)
+	return q
+}
+
+// OrderNullsLast is like Order but sorts NULL values after all others,
+// regardless of direction.
+func (q *QueryBuilder) OrderNullsLast(column string, ascending bool) *QueryBuilder {
+	q.Order(column, ascending)
+	q.orders[len(q.orders)-1] += ".nullslast"
 	return q
 }
 
@@ -114,9 +165,15 @@ func (q *QueryBuilder) WithToken(token string) *QueryBuilder {
 
 // Execute runs the query and unmarshals the result into dest.
 func (q *QueryBuilder) Execute(dest interface{}) error {
+	return q.ExecuteContext(context.Background(), dest)
+}
+
+// ExecuteContext runs the query and unmarshals the result into dest.
+// The request is aborted if ctx is cancelled or its deadline expires.
+func (q *QueryBuilder) ExecuteContext(ctx context.Context, dest interface{}) error {
 	reqURL := q.buildURL()
 
-	req, err := http.NewRequest("GET", reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return err
 	}
@@ -127,7 +184,7 @@ func (q *QueryBuilder) Execute(dest interface{}) error {
 		req.Header.Set("Accept", "application/vnd.pgrst.object+json")
 	}
 
-	resp, err := q.client.httpClient.Do(req)
+	resp, err := q.client.do(req)
 	if err != nil {
 		return err
 	}
@@ -139,7 +196,7 @@ func (q *QueryBuilder) Execute(dest interface{}) error {
 	}
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("query failed: %s", string(body))
+		return newPostgrestError(resp.StatusCode, body)
 	}
 
 	return json.Unmarshal(body, dest)
@@ -155,12 +212,12 @@ func (q *QueryBuilder) buildURL() string {
 		params.Add(f.Column, fmt.Sprintf("%s.%s", f.Operator, f.Value))
 	}
 
-	if q.orderBy != "" {
-		order := q.orderBy
-		if !q.orderAsc {
-			order += ".desc"
-		}
-		params.Set("order", order)
+	for _, group := range q.orGroups {
+		params.Add("or", group)
+	}
+
+	if len(q.orders) > 0 {
+		params.Set("order", strings.Join(q.orders, ","))
 	}
 
 	if q.limit > 0 {
@@ -187,30 +244,65 @@ func (q *QueryBuilder) setHeaders(req *http.Request) {
 
 // Insert adds one or more rows to the table.
 func (c *Client) Insert(table string, data interface{}, userToken string) error {
-	return c.mutate("POST", table, data, nil, userToken, false)
+	return c.InsertContext(context.Background(), table, data, userToken)
+}
+
+// InsertContext adds one or more rows to the table, aborting if ctx is cancelled.
+func (c *Client) InsertContext(ctx context.Context, table string, data interface{}, userToken string) error {
+	return c.mutate(ctx, "POST", table, data, nil, userToken, false)
 }
 
 // InsertReturning adds rows and returns the inserted data.
 func (c *Client) InsertReturning(table string, data interface{}, result interface{}, userToken string) error {
-	return c.mutateReturning("POST", table, data, nil, userToken, result)
+	return c.InsertReturningContext(context.Background(), table, data, result, userToken)
+}
+
+// InsertReturningContext adds rows and returns the inserted data, aborting if ctx is cancelled.
+func (c *Client) InsertReturningContext(ctx context.Context, table string, data interface{}, result interface{}, userToken string) error {
+	return c.mutateReturning(ctx, "POST", table, data, nil, userToken, result)
 }
 
 // Update modifies rows matching the filters.
 func (c *Client) Update(table string, data interface{}, filters []Filter, userToken string) error {
-	return c.mutate("PATCH", table, data, filters, userToken, false)
+	return c.UpdateContext(context.Background(), table, data, filters, userToken)
+}
+
+// UpdateContext modifies rows matching the filters, aborting if ctx is cancelled.
+func (c *Client) UpdateContext(ctx context.Context, table string, data interface{}, filters []Filter, userToken string) error {
+	return c.mutate(ctx, "PATCH", table, data, filters, userToken, false)
 }
 
 // UpdateReturning modifies rows and returns the updated data.
 func (c *Client) UpdateReturning(table string, data interface{}, filters []Filter, result interface{}, userToken string) error {
-	return c.mutateReturning("PATCH", table, data, filters, userToken, result)
+	return c.UpdateReturningContext(context.Background(), table, data, filters, result, userToken)
+}
+
+// UpdateReturningContext modifies rows and returns the updated data, aborting if ctx is cancelled.
+func (c *Client) UpdateReturningContext(ctx context.Context, table string, data interface{}, filters []Filter, result interface{}, userToken string) error {
+	return c.mutateReturning(ctx, "PATCH", table, data, filters, userToken, result)
 }
 
 // Delete removes rows matching the filters.
 func (c *Client) Delete(table string, filters []Filter, userToken string) error {
-	return c.mutate("DELETE", table, nil, filters, userToken, false)
+	return c.DeleteContext(context.Background(), table, filters, userToken)
+}
+
+// DeleteContext removes rows matching the filters, aborting if ctx is cancelled.
+func (c *Client) DeleteContext(ctx context.Context, table string, filters []Filter, userToken string) error {
+	return c.mutate(ctx, "DELETE", table, nil, filters, userToken, false)
+}
+
+// DeleteReturning removes rows matching the filters and returns them.
+func (c *Client) DeleteReturning(table string, filters []Filter, result interface{}, userToken string) error {
+	return c.DeleteReturningContext(context.Background(), table, filters, result, userToken)
+}
+
+// DeleteReturningContext removes rows matching the filters and returns them, aborting if ctx is cancelled.
+func (c *Client) DeleteReturningContext(ctx context.Context, table string, filters []Filter, result interface{}, userToken string) error {
+	return c.mutateReturning(ctx, "DELETE", table, nil, filters, userToken, result)
 }
 
-func (c *Client) mutate(method, table string, data interface{}, filters []Filter, userToken string, returning bool) error {
+func (c *Client) mutate(ctx context.Context, method, table string, data interface{}, filters []Filter, userToken string, returning bool) error {
 	reqURL := c.buildMutateURL(table, filters)
 
 	var body []byte
@@ -222,14 +314,14 @@ func (c *Client) mutate(method, table string, data interface{}, filters []Filter
 		}
 	}
 
-	req, err := http.NewRequest(method, reqURL, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
 
 	c.setMutateHeaders(req, userToken, returning)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -237,13 +329,13 @@ func (c *Client) mutate(method, table string, data interface{}, filters []Filter
 
 	if resp.StatusCode >= 400 {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("%s failed: %s", method, string(respBody))
+		return newPostgrestError(resp.StatusCode, respBody)
 	}
 
 	return nil
 }
 
-func (c *Client) mutateReturning(method, table string, data interface{}, filters []Filter, userToken string, result interface{}) error {
+func (c *Client) mutateReturning(ctx context.Context, method, table string, data interface{}, filters []Filter, userToken string, result interface{}) error {
 	reqURL := c.buildMutateURL(table, filters)
 
 	var body []byte
@@ -255,7 +347,7 @@ func (c *Client) mutateReturning(method, table string, data interface{}, filters
 		}
 	}
 
-	req, err := http.NewRequest(method, reqURL, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
@@ -263,7 +355,7 @@ func (c *Client) mutateReturning(method, table string, data interface{}, filters
 	c.setMutateHeaders(req, userToken, true)
 	req.Header.Set("Prefer", "return=representation")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -275,7 +367,7 @@ func (c *Client) mutateReturning(method, table string, data interface{}, filters
 	}
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("%s failed: %s", method, string(respBody))
+		return newPostgrestError(resp.StatusCode, respBody)
 	}
 
 	return json.Unmarshal(respBody, result)
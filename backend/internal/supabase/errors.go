@@ -0,0 +1,131 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// PostgreSQL and PostgREST error codes the API reacts to.
+const (
+	CodeNoRows              = "PGRST116"
+	CodeUniqueViolation     = "23505"
+	CodeForeignKeyViolation = "23503"
+	CodeNotNullViolation    = "23502"
+	CodeCheckViolation      = "23514"
+	CodeInvalidTextRepr     = "22P02"
+	CodeInsufficientPriv    = "42501"
+)
+
+// PostgrestError represents an error response returned by PostgREST.
+type PostgrestError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details string `json:"details"`
+	Hint    string `json:"hint"`
+}
+
+// Error implements the error interface.
+func (e *PostgrestError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("postgrest %d (%s): %s", e.Status, e.Code, e.Message)
+	}
+	return fmt.Sprintf("postgrest %d: %s", e.Status, e.Message)
+}
+
+// newPostgrestError builds a PostgrestError from a failed response.
+// Bodies that are not PostgREST JSON are kept verbatim in Message.
+func newPostgrestError(status int, body []byte) *PostgrestError {
+	pgErr := &PostgrestError{}
+	if err := json.Unmarshal(body, pgErr); err != nil || pgErr.Message == "" {
+		pgErr = &PostgrestError{Message: string(body)}
+	}
+	pgErr.Status = status
+	return pgErr
+}
+
+// AsPostgrestError extracts a PostgrestError from err, if present.
+func AsPostgrestError(err error) (*PostgrestError, bool) {
+	var pgErr *PostgrestError
+	if errors.As(err, &pgErr) {
+		return pgErr, true
+	}
+	return nil, false
+}
+
+// IsNotFound reports whether err means the requested row or user does not
+// exist.
+func IsNotFound(err error) bool {
+	if authErr, ok := AsAuthAPIError(err); ok {
+		return authErr.Status == http.StatusNotFound
+	}
+	pgErr, ok := AsPostgrestError(err)
+	if !ok {
+		return false
+	}
+	return pgErr.Code == CodeNoRows || pgErr.Status == http.StatusNotFound
+}
+
+// IsUniqueViolation reports whether err is a unique constraint violation.
+func IsUniqueViolation(err error) bool {
+	return hasCode(err, CodeUniqueViolation)
+}
+
+// IsForeignKeyViolation reports whether err is a foreign key violation.
+func IsForeignKeyViolation(err error) bool {
+	return hasCode(err, CodeForeignKeyViolation)
+}
+
+// IsConstraintViolation reports whether err was caused by data that fails
+// a NOT NULL or CHECK constraint, or cannot be parsed as the column type.
+func IsConstraintViolation(err error) bool {
+	return hasCode(err, CodeNotNullViolation) ||
+		hasCode(err, CodeCheckViolation) ||
+		hasCode(err, CodeInvalidTextRepr)
+}
+
+// IsRLSDenied reports whether err was caused by a row level security policy
+// or missing privileges for the current token.
+func IsRLSDenied(err error) bool {
+	pgErr, ok := AsPostgrestError(err)
+	if !ok {
+		return false
+	}
+	return pgErr.Code == CodeInsufficientPriv || pgErr.Status == http.StatusForbidden
+}
+
+// IsUnavailable reports whether err means Supabase could not be reached or
+// did not answer in time.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+	if pgErr, ok := AsPostgrestError(err); ok {
+		switch pgErr.Status {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	if authErr, ok := AsAuthAPIError(err); ok {
+		switch authErr.Status {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func hasCode(err error, code string) bool {
+	pgErr, ok := AsPostgrestError(err)
+	return ok && pgErr.Code == code
+}
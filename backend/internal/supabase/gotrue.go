@@ -0,0 +1,115 @@
+package supabase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// AuthAPIError represents an error response returned by GoTrue.
+type AuthAPIError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+// Error implements the error interface.
+func (e *AuthAPIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	}
+	return e.Message
+}
+
+// newAuthAPIError builds an AuthAPIError from a failed response. GoTrue
+// reports errors in a few shapes depending on the endpoint and version.
+func newAuthAPIError(status int, body []byte) *AuthAPIError {
+	var payload struct {
+		ErrorCode   string `json:"error_code"`
+		Msg         string `json:"msg"`
+		Message     string `json:"message"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return &AuthAPIError{Status: status, Message: string(body)}
+	}
+
+	authErr := &AuthAPIError{Status: status, Code: payload.ErrorCode}
+	if authErr.Code == "" {
+		authErr.Code = payload.Error
+	}
+	for _, message := range []string{payload.Msg, payload.Description, payload.Message} {
+		if message != "" {
+			authErr.Message = message
+			break
+		}
+	}
+	if authErr.Message == "" {
+		authErr.Message = string(body)
+	}
+	return authErr
+}
+
+// AsAuthAPIError extracts an AuthAPIError from err, if present.
+func AsAuthAPIError(err error) (*AuthAPIError, bool) {
+	var authErr *AuthAPIError
+	if errors.As(err, &authErr) {
+		return authErr, true
+	}
+	return nil, false
+}
+
+// gotrue sends a JSON request to a GoTrue endpoint and decodes the response
+// into dest, if not nil. bearer is sent as the Authorization token when set.
+// It returns the response headers.
+func (c *Client) gotrue(ctx context.Context, method, endpoint, bearer string, payload, dest interface{}) (http.Header, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("apikey", c.apiKey)
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, newAuthAPIError(resp.StatusCode, respBody)
+	}
+
+	if dest != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, dest); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp.Header, nil
+}
@@ -0,0 +1,104 @@
+package supabase
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Factor is an MFA factor enrolled by a user.
+type Factor struct {
+	ID           string `json:"id"`
+	FriendlyName string `json:"friendly_name,omitempty"`
+	FactorType   string `json:"factor_type"`
+	// Status is "unverified" until the first successful verification.
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TOTPEnrollment is a newly enrolled TOTP factor, with what the user needs
+// to add it to an authenticator app.
+type TOTPEnrollment struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	FriendlyName string `json:"friendly_name,omitempty"`
+	TOTP         struct {
+		// QRCode is an SVG image of URI.
+		QRCode string `json:"qr_code"`
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	} `json:"totp"`
+}
+
+// Challenge is an MFA challenge to verify with a code.
+type Challenge struct {
+	ID        string `json:"id"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// The MFA methods below act on the user accessToken belongs to.
+
+// CurrentUser returns the user accessToken belongs to, including their
+// factors.
+func (c *Client) CurrentUser(ctx context.Context, accessToken string) (*User, error) {
+	var user User
+	if _, err := c.gotrue(ctx, http.MethodGet, "/auth/v1/user", accessToken, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// EnrollTOTP enrolls a new, unverified TOTP factor. issuer is shown in
+// authenticator apps.
+func (c *Client) EnrollTOTP(ctx context.Context, accessToken, friendlyName, issuer string) (*TOTPEnrollment, error) {
+	payload := map[string]string{
+		"factor_type": "totp",
+	}
+	if friendlyName != "" {
+		payload["friendly_name"] = friendlyName
+	}
+	if issuer != "" {
+		payload["issuer"] = issuer
+	}
+
+	var enrollment TOTPEnrollment
+	if _, err := c.gotrue(ctx, http.MethodPost, "/auth/v1/factors", accessToken, payload, &enrollment); err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+// ChallengeFactor creates a challenge for factorID.
+func (c *Client) ChallengeFactor(ctx context.Context, accessToken, factorID string) (*Challenge, error) {
+	var challenge Challenge
+	endpoint := "/auth/v1/factors/" + url.PathEscape(factorID) + "/challenge"
+	if _, err := c.gotrue(ctx, http.MethodPost, endpoint, accessToken, nil, &challenge); err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// VerifyFactor verifies code for a challenge of factorID. It returns a new
+// session at assurance level aal2, and marks an unverified factor verified.
+func (c *Client) VerifyFactor(ctx context.Context, accessToken, factorID, challengeID, code string) (*AuthResponse, error) {
+	payload := map[string]string{
+		"challenge_id": challengeID,
+		"code":         code,
+	}
+
+	var authResp AuthResponse
+	endpoint := "/auth/v1/factors/" + url.PathEscape(factorID) + "/verify"
+	if _, err := c.gotrue(ctx, http.MethodPost, endpoint, accessToken, payload, &authResp); err != nil {
+		return nil, err
+	}
+	return &authResp, nil
+}
+
+// UnenrollFactor removes factorID. Removing a verified factor needs an
+// aal2 session.
+func (c *Client) UnenrollFactor(ctx context.Context, accessToken, factorID string) error {
+	_, err := c.gotrue(ctx, http.MethodDelete, "/auth/v1/factors/"+url.PathEscape(factorID), accessToken, nil, nil)
+	return err
+}
@@ -0,0 +1,256 @@
+package supabase
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when the circuit breaker is rejecting calls
+// because Supabase has been failing.
+var ErrCircuitOpen = errors.New("supabase: circuit breaker open")
+
+// RetryPolicy configures retries for transient upstream failures.
+// Only idempotent requests (GET, HEAD, OPTIONS, PUT, DELETE) are retried.
+// A Retry-After header on the failed response replaces the backoff; if it
+// asks for longer than MaxDelay the response is returned instead.
+type RetryPolicy struct {
+	// MaxRetries is the number of additional attempts after the first one.
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry; it doubles per attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between two attempts.
+	MaxDelay time.Duration
+}
+
+// backoff returns the jittered delay before retry number attempt (0-based).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << attempt
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// Full jitter spreads retries from many clients over the whole window.
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// Option configures optional Client behaviour.
+type Option func(*Client)
+
+// WithRetry enables retries for transient failures.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithCircuitBreaker makes the client fail fast while breaker is open.
+func WithCircuitBreaker(breaker *CircuitBreaker) Option {
+	return func(c *Client) {
+		c.breaker = breaker
+	}
+}
+
+// WithHTTPClient replaces the underlying HTTP client.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// circuitState is the state of a CircuitBreaker.
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreaker stops calls to Supabase after a run of consecutive failures
+// and lets a single probe through once the cooldown has elapsed.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+	now      func() time.Time
+}
+
+// NewCircuitBreaker creates a breaker that opens after threshold consecutive
+// failures and stays open for cooldown.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow reports whether a call may proceed.
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		// A probe is already in flight.
+		return false
+	default:
+		return true
+	}
+}
+
+// record updates the breaker with the outcome of a call.
+func (b *CircuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.state = circuitClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.threshold {
+		b.state = circuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// abandon releases a half-open probe whose caller gave up, so that the next
+// call can probe again instead of the breaker staying stuck.
+func (b *CircuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitHalfOpen {
+		b.state = circuitOpen
+		b.openedAt = b.now().Add(-b.cooldown)
+	}
+}
+
+// do sends req applying the configured retry policy and circuit breaker.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	retries := 0
+	if isIdempotent(req.Method) {
+		retries = c.retry.MaxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		if c.breaker != nil && !c.breaker.allow() {
+			return nil, ErrCircuitOpen
+		}
+
+		resp, err := c.httpClient.Do(req)
+		transient := isTransient(req.Context(), resp, err)
+		if c.breaker != nil {
+			if req.Context().Err() != nil {
+				c.breaker.abandon()
+			} else {
+				c.breaker.record(!transient)
+			}
+		}
+
+		if !transient || attempt >= retries {
+			return resp, err
+		}
+
+		delay := c.retry.backoff(attempt)
+		if wait, ok := retryAfter(resp); ok {
+			if c.retry.MaxDelay > 0 && wait > c.retry.MaxDelay {
+				return resp, err
+			}
+			delay = wait
+		}
+
+		// Rewind the body for the next attempt.
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			req.Body = body
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		if sleepErr := sleepContext(req.Context(), delay); sleepErr != nil {
+			return nil, sleepErr
+		}
+	}
+}
+
+// isIdempotent reports whether a request with method can be safely repeated.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isTransient reports whether a call failed in a way worth retrying.
+func isTransient(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		// The caller gave up; retrying cannot help.
+		return ctx.Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns how long the Retry-After header of resp asks to wait,
+// given in seconds or as an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
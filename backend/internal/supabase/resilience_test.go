@@ -0,0 +1,340 @@
+package supabase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer is an httptest stand-in for Supabase that fails its first
+// requests with fail and then answers 200 OK.
+type flakyServer struct {
+	*httptest.Server
+	failures int32
+	fail     http.HandlerFunc
+
+	calls int32
+	mu    sync.Mutex
+	// bodies are the request bodies received, in order.
+	bodies []string
+}
+
+func newFlakyServer(t *testing.T, failures int, fail http.HandlerFunc) *flakyServer {
+	t.Helper()
+
+	s := &flakyServer{failures: int32(failures), fail: fail}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+	// Fresh connections per request, so that the transport never retries a
+	// reset connection on its own.
+	s.Config.SetKeepAlivesEnabled(false)
+	s.Start()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *flakyServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.bodies = append(s.bodies, string(body))
+	s.mu.Unlock()
+
+	if atomic.AddInt32(&s.calls, 1) <= s.failures {
+		s.fail(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *flakyServer) Calls() int {
+	return int(atomic.LoadInt32(&s.calls))
+}
+
+// status fails requests with code.
+func status(code int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	}
+}
+
+// connectionReset drops the connection with a TCP reset.
+func connectionReset(w http.ResponseWriter, r *http.Request) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		panic(err)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		_ = tcp.SetLinger(0)
+	}
+	conn.Close()
+}
+
+func newTestClient(s *flakyServer, opts ...Option) *Client {
+	opts = append([]Option{WithHTTPClient(s.Client())}, opts...)
+	return NewClient(s.URL, "test-key", opts...)
+}
+
+func newRequest(t *testing.T, ctx context.Context, method, url, body string) *http.Request {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestClientRetries(t *testing.T) {
+	retry := RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	tests := []struct {
+		name       string
+		method     string
+		failures   int
+		fail       http.HandlerFunc
+		wantStatus int
+		wantErr    bool
+		wantCalls  int
+	}{
+		{name: "retries 502", method: http.MethodGet, failures: 2, fail: status(http.StatusBadGateway), wantStatus: http.StatusOK, wantCalls: 3},
+		{name: "retries 503", method: http.MethodGet, failures: 2, fail: status(http.StatusServiceUnavailable), wantStatus: http.StatusOK, wantCalls: 3},
+		{name: "retries 504", method: http.MethodGet, failures: 2, fail: status(http.StatusGatewayTimeout), wantStatus: http.StatusOK, wantCalls: 3},
+		{name: "retries connection reset", method: http.MethodGet, failures: 2, fail: connectionReset, wantStatus: http.StatusOK, wantCalls: 3},
+		{name: "retries idempotent PUT", method: http.MethodPut, failures: 1, fail: status(http.StatusServiceUnavailable), wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "retries DELETE", method: http.MethodDelete, failures: 1, fail: connectionReset, wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "gives up after max retries", method: http.MethodGet, failures: 5, fail: status(http.StatusServiceUnavailable), wantStatus: http.StatusServiceUnavailable, wantCalls: 3},
+		{name: "does not retry 400", method: http.MethodGet, failures: 1, fail: status(http.StatusBadRequest), wantStatus: http.StatusBadRequest, wantCalls: 1},
+		{name: "does not retry 404", method: http.MethodGet, failures: 1, fail: status(http.StatusNotFound), wantStatus: http.StatusNotFound, wantCalls: 1},
+		{name: "does not retry 429", method: http.MethodGet, failures: 1, fail: status(http.StatusTooManyRequests), wantStatus: http.StatusTooManyRequests, wantCalls: 1},
+		{name: "does not retry 500", method: http.MethodGet, failures: 1, fail: status(http.StatusInternalServerError), wantStatus: http.StatusInternalServerError, wantCalls: 1},
+		{name: "does not retry POST on 503", method: http.MethodPost, failures: 1, fail: status(http.StatusServiceUnavailable), wantStatus: http.StatusServiceUnavailable, wantCalls: 1},
+		{name: "does not retry POST on connection reset", method: http.MethodPost, failures: 1, fail: connectionReset, wantErr: true, wantCalls: 1},
+		{name: "does not retry PATCH", method: http.MethodPatch, failures: 1, fail: status(http.StatusBadGateway), wantStatus: http.StatusBadGateway, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFlakyServer(t, tt.failures, tt.fail)
+			client := newTestClient(server, WithRetry(retry))
+
+			resp, err := client.do(newRequest(t, context.Background(), tt.method, server.URL, `{"title":"a"}`))
+			if tt.wantErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatalf("got status %d, want error", resp.StatusCode)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("do: %v", err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != tt.wantStatus {
+					t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+			}
+
+			if got := server.Calls(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			for i, body := range server.bodies {
+				if body != `{"title":"a"}` {
+					t.Errorf("attempt %d sent body %q", i+1, body)
+				}
+			}
+		})
+	}
+}
+
+func TestClientHonorsRetryAfter(t *testing.T) {
+	retryAfter := func(value string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", value)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}
+
+	seconds := func(value string) func() string {
+		return func() string { return value }
+	}
+	// HTTP dates have whole seconds, so this waits between one and two.
+	inTwoSeconds := func() string {
+		return time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat)
+	}
+
+	tests := []struct {
+		name       string
+		retryAfter func() string
+		wantStatus int
+		wantCalls  int
+		minElapsed time.Duration
+	}{
+		{name: "waits for Retry-After seconds", retryAfter: seconds("1"), wantStatus: http.StatusOK, wantCalls: 2, minElapsed: time.Second},
+		{name: "waits for Retry-After date", retryAfter: inTwoSeconds, wantStatus: http.StatusOK, wantCalls: 2, minElapsed: 900 * time.Millisecond},
+		{name: "gives up when Retry-After exceeds MaxDelay", retryAfter: seconds("60"), wantStatus: http.StatusServiceUnavailable, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFlakyServer(t, 1, retryAfter(tt.retryAfter()))
+			client := newTestClient(server, WithRetry(RetryPolicy{
+				MaxRetries: 2,
+				BaseDelay:  time.Millisecond,
+				MaxDelay:   3 * time.Second,
+			}))
+
+			start := time.Now()
+			resp, err := client.do(newRequest(t, context.Background(), http.MethodGet, server.URL, ""))
+			if err != nil {
+				t.Fatalf("do: %v", err)
+			}
+			resp.Body.Close()
+			elapsed := time.Since(start)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := server.Calls(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			if elapsed < tt.minElapsed {
+				t.Errorf("retried after %v, want at least %v", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
+
+func TestClientCancelledDuringBackoff(t *testing.T) {
+	server := newFlakyServer(t, 5, status(http.StatusServiceUnavailable))
+	client := newTestClient(server, WithRetry(RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  time.Minute,
+		MaxDelay:   time.Minute,
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := client.do(newRequest(t, ctx, http.MethodGet, server.URL, ""))
+	if err == nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("returned after %v, want on cancellation", elapsed)
+	}
+	// Backoff may be jittered down to zero, in which case a second attempt
+	// is made before the deadline.
+	if got := server.Calls(); got > 2 {
+		t.Errorf("calls = %d, want at most 2", got)
+	}
+}
+
+// fakeClock is a settable time source for circuit breakers.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	breaker := NewCircuitBreaker(3, time.Minute)
+	breaker.now = clock.Now
+
+	server := newFlakyServer(t, 4, status(http.StatusServiceUnavailable))
+	client := newTestClient(server, WithCircuitBreaker(breaker))
+
+	get := func() (int, error) {
+		resp, err := client.do(newRequest(t, context.Background(), http.MethodGet, server.URL, ""))
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	// Failures below the threshold reach the server.
+	for i := 0; i < 3; i++ {
+		if code, err := get(); err != nil || code != http.StatusServiceUnavailable {
+			t.Fatalf("call %d = %d, %v; want 503", i+1, code, err)
+		}
+	}
+
+	// Open: fail fast without calling the server.
+	if _, err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if got := server.Calls(); got != 3 {
+		t.Fatalf("calls = %d, want 3", got)
+	}
+
+	// Half-open after the cooldown: a failed probe opens it again.
+	clock.Advance(time.Minute)
+	if code, err := get(); err != nil || code != http.StatusServiceUnavailable {
+		t.Fatalf("probe = %d, %v; want 503", code, err)
+	}
+	if _, err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err after failed probe = %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful probe closes it.
+	clock.Advance(time.Minute)
+	for i := 0; i < 2; i++ {
+		if code, err := get(); err != nil || code != http.StatusOK {
+			t.Fatalf("call %d after recovery = %d, %v; want 200", i+1, code, err)
+		}
+	}
+	if got := server.Calls(); got != 6 {
+		t.Errorf("calls = %d, want 6", got)
+	}
+}
+
+func TestCircuitBreakerHalfOpenAllowsOneProbe(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.now = clock.Now
+
+	breaker.record(false)
+	if breaker.allow() {
+		t.Fatal("open breaker allowed a call")
+	}
+
+	clock.Advance(time.Minute)
+	if !breaker.allow() {
+		t.Fatal("breaker did not half-open after the cooldown")
+	}
+	if breaker.allow() {
+		t.Fatal("half-open breaker allowed a second probe")
+	}
+
+	// An abandoned probe lets the next call probe again.
+	breaker.abandon()
+	if !breaker.allow() {
+		t.Fatal("breaker did not allow a probe after one was abandoned")
+	}
+}
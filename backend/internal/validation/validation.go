@@ -0,0 +1,199 @@
+// Package validation checks request bodies against their validate struct
+// tags and translates the errors into field messages.
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/pt"
+	"github.com/go-playground/locales/pt_BR"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	pt_translations "github.com/go-playground/validator/v10/translations/pt"
+	pt_BR_translations "github.com/go-playground/validator/v10/translations/pt_BR"
+	"github.com/labstack/echo/v4"
+)
+
+// Validator enforces the validate struct tags of request bodies for
+// echo.Context.Validate, and translates its errors into field errors keyed
+// by JSON field name.
+type Validator struct {
+	validate    *validator.Validate
+	translators *ut.UniversalTranslator
+}
+
+// New creates a validator with English, Spanish, French and Portuguese
+// messages. English is the fallback.
+func New() *Validator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(jsonName)
+	// notblank also rejects the whitespace-only strings required accepts.
+	if err := validate.RegisterValidation("notblank", validators.NotBlank); err != nil {
+		panic("validation: register notblank: " + err.Error())
+	}
+
+	english := en.New()
+	translators := ut.New(english, english, es.New(), fr.New(), pt.New(), pt_BR.New())
+
+	register := map[string]func(*validator.Validate, ut.Translator) error{
+		"en":    en_translations.RegisterDefaultTranslations,
+		"es":    es_translations.RegisterDefaultTranslations,
+		"fr":    fr_translations.RegisterDefaultTranslations,
+		"pt":    pt_translations.RegisterDefaultTranslations,
+		"pt_BR": pt_BR_translations.RegisterDefaultTranslations,
+	}
+	for locale, fn := range register {
+		trans, _ := translators.GetTranslator(locale)
+		err := fn(validate, trans)
+		if err == nil {
+			err = trans.Add(invalidKey, invalidMessages[locale], false)
+		}
+		if err != nil {
+			// The bundled translations are static; failing here is a bug.
+			panic("validation: register " + locale + " translations: " + err.Error())
+		}
+	}
+
+	return &Validator{validate: validate, translators: translators}
+}
+
+// invalidKey is the translation key of the message for tags without one.
+const invalidKey = "invalid"
+
+// invalidMessages are the messages for tags without one, by locale.
+var invalidMessages = map[string]string{
+	"en":    "{0} is invalid",
+	"es":    "{0} no es válido",
+	"fr":    "{0} n'est pas valide",
+	"pt":    "{0} é inválido",
+	"pt_BR": "{0} é inválido",
+}
+
+// similarTags maps tags without bundled messages in every language to a tag
+// whose message fits them.
+var similarTags = map[string]string{
+	"notblank":             "required",
+	"required_if":          "required",
+	"required_unless":      "required",
+	"required_with":        "required",
+	"required_without":     "required",
+	"required_without_all": "required",
+	"http_url":             "url",
+}
+
+// jsonName names struct fields after their JSON key, so that errors refer
+// to the fields clients send.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// Validate implements echo.Validator.
+func (v *Validator) Validate(i interface{}) error {
+	return v.validate.Struct(i)
+}
+
+// FieldErrors translates err, if it is a validation error, into messages
+// keyed by field path (e.g. "title" or "items[2].title"), in the best
+// language of an Accept-Language header value.
+func (v *Validator) FieldErrors(err error, acceptLanguage string) (map[string]string, bool) {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return nil, false
+	}
+
+	trans, _ := v.translators.FindTranslator(languages(acceptLanguage)...)
+
+	fields := make(map[string]string, len(invalid))
+	for _, fe := range invalid {
+		key := fe.Namespace()
+		// Drop the name of the validated struct itself.
+		if _, rest, ok := strings.Cut(key, "."); ok {
+			key = rest
+		}
+		if _, seen := fields[key]; !seen {
+			fields[key] = message(translate(trans, fe), fe.Field())
+		}
+	}
+
+	return fields, true
+}
+
+// translate returns the message of fe in trans. Tags without a bundled
+// message get the message of a similar tag, or else a generic one.
+func translate(trans ut.Translator, fe validator.FieldError) string {
+	if msg := fe.Translate(trans); msg != fe.Error() {
+		return msg
+	}
+
+	tag := fe.Tag()
+	// Of alternatives such as eq=|http_url, the last one is the meaningful
+	// one.
+	if i := strings.LastIndex(tag, "|"); i >= 0 {
+		tag = tag[i+1:]
+	}
+	if similar, ok := similarTags[tag]; ok {
+		tag = similar
+	}
+	if msg, err := trans.T(tag, fe.Field()); err == nil {
+		return msg
+	}
+
+	msg, _ := trans.T(invalidKey, fe.Field())
+	return msg
+}
+
+// message makes a translated message read naturally: the field name is
+// spelled out and the first letter capitalized.
+func message(msg, field string) string {
+	msg = strings.Replace(msg, field, strings.ReplaceAll(field, "_", " "), 1)
+	r, size := utf8.DecodeRuneInString(msg)
+	return string(unicode.ToUpper(r)) + msg[size:]
+}
+
+// languages lists the locales of an Accept-Language header value in order
+// of appearance, each followed by its base language (pt-BR, pt). Quality
+// values are ignored; clients list languages by preference.
+func languages(header string) []string {
+	var locales []string
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		tag = strings.ReplaceAll(strings.TrimSpace(tag), "-", "_")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		locales = append(locales, tag)
+		if base, _, ok := strings.Cut(tag, "_"); ok {
+			locales = append(locales, base)
+		}
+	}
+	return locales
+}
+
+// Errors translates err into field errors if it is a validation error
+// returned by c.Validate, using the validator registered with c's echo
+// instance and the request's Accept-Language header.
+func Errors(c echo.Context, err error) (map[string]string, bool) {
+	v, ok := c.Echo().Validator.(*Validator)
+	if !ok {
+		return nil, false
+	}
+	return v.FieldErrors(err, c.Request().Header.Get("Accept-Language"))
+}
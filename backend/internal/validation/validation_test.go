@@ -0,0 +1,88 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+)
+
+type item struct {
+	Title string `json:"title" validate:"required,notblank"`
+}
+
+type request struct {
+	Op        string  `json:"op" validate:"required,oneof=create delete"`
+	Items     []item  `json:"items" validate:"required_if=Op create,omitempty,max=2,dive"`
+	AvatarURL *string `json:"avatar_url" validate:"omitnil,eq=|http_url"`
+	Timezone  *string `json:"timezone" validate:"omitnil,eq=|timezone"`
+}
+
+func ptr(s string) *string { return &s }
+
+func TestFieldErrors(t *testing.T) {
+	v := New()
+
+	tests := []struct {
+		name           string
+		req            request
+		acceptLanguage string
+		want           map[string]string
+	}{
+		{
+			name: "valid",
+			req:  request{Op: "create", Items: []item{{Title: "a"}}, AvatarURL: ptr(""), Timezone: ptr("Europe/Madrid")},
+		},
+		{
+			name: "blank title",
+			req:  request{Op: "create", Items: []item{{Title: "a"}, {Title: "  "}}},
+			want: map[string]string{"items[1].title": "Title is a required field"},
+		},
+		{
+			name: "conditionally required",
+			req:  request{Op: "create"},
+			want: map[string]string{"items": "Items is a required field"},
+		},
+		{
+			name: "alternatives",
+			req:  request{Op: "delete", AvatarURL: ptr("ftp://example.com"), Timezone: ptr("Mars/Olympus")},
+			want: map[string]string{
+				"avatar_url": "Avatar url must be a valid URL",
+				"timezone":   "Timezone is invalid",
+			},
+		},
+		{
+			name:           "translated",
+			req:            request{Op: "update", Timezone: ptr("Mars/Olympus")},
+			acceptLanguage: "fr-CA,fr;q=0.9",
+			want: map[string]string{
+				"op":       "Op doit être l'un des choix suivants [create delete]",
+				"timezone": "Timezone n'est pas valide",
+			},
+		},
+		{
+			name:           "translated like a similar tag",
+			req:            request{Op: "create", Items: []item{{Title: " "}}},
+			acceptLanguage: "pt-BR",
+			want:           map[string]string{"items[0].title": "Title é um campo obrigatório"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(&tt.req)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+
+			got, ok := v.FieldErrors(err, tt.acceptLanguage)
+			if !ok {
+				t.Fatalf("FieldErrors(%v) is not a validation error", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FieldErrors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,6 @@
 import Constants from "expo-constants";
 import * as SecureStore from "expo-secure-store";
+import { Platform } from "react-native";
 
 const API_URL =
   process.env.API_URL ||
@@ -23,6 +24,7 @@ export interface AuthResponse {
 export interface AuthError {
   error: string;
   message: string;
+  details?: Record<string, string>;
 }
 
 export interface LoginCredentials {
@@ -33,6 +35,7 @@ export interface LoginCredentials {
 export interface RegisterCredentials {
   email: string;
   password: string;
+  invite_code?: string;
 }
 
 // Token storage keys
@@ -66,6 +69,15 @@ export async function clearTokens(): Promise<void> {
   await SecureStore.deleteItemAsync(USER_KEY);
 }
 
+// Describes this device for the backend's list of signed-in sessions
+function deviceHeaders(): Record<string, string> {
+  return {
+    "X-Device-Name": Constants.deviceName ?? "",
+    "X-Platform": Platform.OS,
+    "X-App-Version": Constants.expoConfig?.version ?? "",
+  };
+}
+
 // Auth API functions
 export async function register(
   credentials: RegisterCredentials
@@ -74,6 +86,7 @@ export async function register(
     method: "POST",
     headers: {
       "Content-Type": "application/json",
+      ...deviceHeaders(),
     },
     body: JSON.stringify(credentials),
   });
@@ -81,7 +94,10 @@ export async function register(
   const data = await response.json();
 
   if (!response.ok) {
-    throw new Error((data as AuthError).message || "Registration failed");
+    const error = data as AuthError;
+    // Show the first field error rather than the generic message
+    const detail = error.details && Object.values(error.details)[0];
+    throw new Error(detail || error.message || "Registration failed");
   }
 
   await storeTokens(data as AuthResponse);
@@ -95,6 +111,7 @@ export async function login(
     method: "POST",
     headers: {
       "Content-Type": "application/json",
+      ...deviceHeaders(),
     },
     body: JSON.stringify(credentials),
   });
@@ -139,6 +156,7 @@ export async function refreshToken(): Promise<AuthResponse> {
     method: "POST",
     headers: {
       "Content-Type": "application/json",
+      ...deviceHeaders(),
     },
     body: JSON.stringify({ refresh_token: refresh }),
   });